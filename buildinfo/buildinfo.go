@@ -0,0 +1,71 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Version and Commit are set at build time:
+// go build -ldflags "-X github.com/leventeberry/goapi/buildinfo.Version=v1.2.3 -X github.com/leventeberry/goapi/buildinfo.Commit=abc123"
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// startedAt is when the process started, for uptime reporting
+var startedAt = time.Now()
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	CommitAt  string `json:"commit_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+	Platform  string `json:"platform"`
+	StartedAt string `json:"started_at"`
+	Uptime    string `json:"uptime"`
+	// Deps lists module dependencies as path@version
+	Deps []string `json:"deps,omitempty"`
+}
+
+// Get returns the build information for the running binary
+// VCS details embedded by the Go toolchain fill in what -ldflags did not set
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = build.GoVersion
+	info.Module = build.Main.Path
+	if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			info.CommitAt = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	for _, dep := range build.Deps {
+		info.Deps = append(info.Deps, dep.Path+"@"+dep.Version)
+	}
+	return info
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/leventeberry/goapi/models"
+)
+
+// CachedUser is the cached representation of models.User
+// It lists exactly which user fields are cached. Never cached:
+//   - PassHash: credentials must always be verified against the database,
+//     so a user read from cache cannot be used to check a password
+//
+// Adding, removing or retyping a field here requires bumping KeyVersion
+type CachedUser struct {
+	ID        int       `json:"id" msgpack:"id"`
+	FirstName string    `json:"first_name" msgpack:"first_name"`
+	LastName  string    `json:"last_name" msgpack:"last_name"`
+	Email     string    `json:"email" msgpack:"email"`
+	PhoneNum  string    `json:"phone_number" msgpack:"phone_number"`
+	Role      string    `json:"role" msgpack:"role"`
+	CreatedAt time.Time `json:"created_at" msgpack:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" msgpack:"updated_at"`
+}
+
+// NewCachedUser copies the cacheable fields of user
+func NewCachedUser(user *models.User) *CachedUser {
+	return &CachedUser{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		PhoneNum:  user.PhoneNum,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// ToModel converts the cached user back to a models.User (with an empty PassHash)
+func (u *CachedUser) ToModel() *models.User {
+	return &models.User{
+		ID:        u.ID,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		PhoneNum:  u.PhoneNum,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// userCodec stores models.User values as CachedUser using an underlying codec
+type userCodec struct {
+	inner Codec[CachedUser]
+}
+
+// NewUserCodec returns a Codec for models.User that only persists the CachedUser fields
+func NewUserCodec(inner Codec[CachedUser]) Codec[models.User] {
+	return userCodec{inner: inner}
+}
+
+// Name returns the name of the underlying codec
+func (c userCodec) Name() string {
+	return c.inner.Name()
+}
+
+// Encode stores the cacheable fields of user
+func (c userCodec) Encode(user *models.User) (string, error) {
+	return c.inner.Encode(NewCachedUser(user))
+}
+
+// Decode restores a user from its cached form
+func (c userCodec) Decode(data string) (*models.User, error) {
+	cached, err := c.inner.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return cached.ToModel(), nil
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec names accepted by NewCodec (CACHE_CODEC)
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+	CodecGob     = "gob"
+)
+
+// Codec converts values of type T to and from their cached string form
+// Binary codecs store raw bytes in the string; Redis values are binary-safe
+type Codec[T any] interface {
+	Name() string // Registered name, part of the keys of values encoded with the codec
+	Encode(value *T) (string, error)
+	Decode(data string) (*T, error)
+}
+
+// NewCodec returns the codec registered under name
+func NewCodec[T any](name string) (Codec[T], error) {
+	switch name {
+	case CodecJSON, "":
+		return JSONCodec[T]{}, nil
+	case CodecMsgpack:
+		return MsgpackCodec[T]{}, nil
+	case CodecGob:
+		return GobCodec[T]{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q", name)
+	}
+}
+
+// JSONCodec encodes values as JSON
+// Note that fields tagged `json:"-"` are not cached
+type JSONCodec[T any] struct{}
+
+// Name returns CodecJSON
+func (JSONCodec[T]) Name() string {
+	return CodecJSON
+}
+
+// Encode marshals value to JSON
+func (JSONCodec[T]) Encode(value *T) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Decode unmarshals a JSON value
+func (JSONCodec[T]) Decode(data string) (*T, error) {
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// MsgpackCodec encodes values as MessagePack
+// Smaller and faster than JSON; honors `msgpack` struct tags
+type MsgpackCodec[T any] struct{}
+
+// Name returns CodecMsgpack
+func (MsgpackCodec[T]) Name() string {
+	return CodecMsgpack
+}
+
+// Encode marshals value to MessagePack
+func (MsgpackCodec[T]) Encode(value *T) (string, error) {
+	data, err := msgpack.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Decode unmarshals a MessagePack value
+func (MsgpackCodec[T]) Decode(data string) (*T, error) {
+	var value T
+	if err := msgpack.Unmarshal([]byte(data), &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// GobCodec encodes values with encoding/gob
+// Each value carries its own type description, so it suits Go-only consumers
+type GobCodec[T any] struct{}
+
+// Name returns CodecGob
+func (GobCodec[T]) Name() string {
+	return CodecGob
+}
+
+// Encode marshals value with gob
+func (GobCodec[T]) Encode(value *T) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Decode unmarshals a gob value
+func (GobCodec[T]) Decode(data string) (*T, error) {
+	var value T
+	if err := gob.NewDecoder(bytes.NewReader([]byte(data))).Decode(&value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
@@ -3,20 +3,56 @@ package cache
 import "time"
 
 // Cache key patterns
+// Entity keys carry a schema version segment right after the entity prefix
+// Bump KeyVersion whenever the cached representation changes so a deploy
+// starts from fresh keys instead of decoding entries written by the old code
 const (
-	// UserIDKeyPrefix is the prefix for user cache keys by ID
-	// Full key format: "user:id:{id}"
-	UserIDKeyPrefix = "user:id:"
-	
-	// UserEmailKeyPrefix is the prefix for user cache keys by email
-	// Full key format: "user:email:{email}"
-	UserEmailKeyPrefix = "user:email:"
-	
+	// KeyVersion is the schema version segment of entity cache keys
+	KeyVersion = "v1"
+
+	// UserKeyPrefix is the prefix shared by every user cache key
+	UserKeyPrefix = "user:"
+
+	// UserNotFoundIDKeyPrefix is the prefix for negative cache entries of missing user IDs
+	// Full key format: "user:v1:notfound:id:{id}"
+	UserNotFoundIDKeyPrefix = UserKeyPrefix + KeyVersion + ":notfound:id:"
+
+	// UserNotFoundEmailKeyPrefix is the prefix for negative cache entries of missing emails
+	// Full key format: "user:v1:notfound:email:{email}"
+	UserNotFoundEmailKeyPrefix = UserKeyPrefix + KeyVersion + ":notfound:email:"
+
 	// RateLimitKeyPrefix is the prefix for rate limiting keys
 	// Full key format: "ratelimit:{key}"
 	RateLimitKeyPrefix = "ratelimit:"
+
+	// IPFilterKeyPrefix is the prefix for IP allow/deny rules and bans
+	IPFilterKeyPrefix = "ipfilter:"
+
+	// IPRulesKey holds the admin-managed IP rules as a JSON list
+	IPRulesKey = IPFilterKeyPrefix + "rules"
+
+	// IPBanKeyPrefix is the prefix for temporary IP bans
+	// Full key format: "ipfilter:ban:{ip}"
+	IPBanKeyPrefix = IPFilterKeyPrefix + "ban:"
+
+	// RateLimitBucketKeyPrefix is the prefix for token bucket state
+	// Full key format: "ratelimit:bucket:{key}"
+	RateLimitBucketKeyPrefix = RateLimitKeyPrefix + "bucket:"
 )
 
+// UserIDKeyPrefix returns the prefix for user cache keys by ID encoded with codec
+// The codec name is part of the key so changing CACHE_CODEC never decodes entries in the old format
+// Full key format: "user:v1:{codec}:id:{id}"
+func UserIDKeyPrefix(codec string) string {
+	return UserKeyPrefix + KeyVersion + ":" + codec + ":id:"
+}
+
+// UserEmailKeyPrefix returns the prefix for user cache keys by email encoded with codec
+// Full key format: "user:v1:{codec}:email:{email}"
+func UserEmailKeyPrefix(codec string) string {
+	return UserKeyPrefix + KeyVersion + ":" + codec + ":email:"
+}
+
 // Cache TTL (Time To Live) values
 const (
 	// UserCacheTTL is the default TTL for cached user objects
@@ -24,10 +60,19 @@ const (
 	// User data changes infrequently, so 15 minutes reduces database load
 	// while ensuring reasonable data freshness
 	UserCacheTTL = 15 * time.Minute
-	
+
+	// UserNotFoundCacheTTL is the TTL for negative cache entries (lookups that found no user)
+	// Kept short so a user created through another path becomes visible quickly
+	UserNotFoundCacheTTL = 30 * time.Second
+
+	// TTLJitterFraction is the maximum random deviation applied by WithJitter (10%)
+	TTLJitterFraction = 0.1
+
 	// RateLimitWindow is the default window for rate limiting
 	// Set to 1 minute to match typical rate limiting requirements
 	// This matches the default rate limiter configuration
 	RateLimitWindow = 1 * time.Minute
 )
 
+// NoExpiration is returned by TTL for keys that never expire
+const NoExpiration time.Duration = -1
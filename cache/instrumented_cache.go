@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// instrumentedCache implements Cache interface by recording stats around another Cache
+// Wraps every backend so hit ratios can be compared across redis, tiered and memory
+type instrumentedCache struct {
+	inner Cache
+	stats *Stats
+}
+
+// NewInstrumentedCache wraps inner so every operation is counted in stats
+func NewInstrumentedCache(inner Cache, stats *Stats) Cache {
+	return &instrumentedCache{inner: inner, stats: stats}
+}
+
+// StatsOf returns the stats collected for c, or nil if c is not instrumented
+func StatsOf(c Cache) *Stats {
+	if ic, ok := c.(*instrumentedCache); ok {
+		return ic.stats
+	}
+	return nil
+}
+
+// observe records an operation that started at start
+func (c *instrumentedCache) observe(family string, start time.Time, result outcome, err error) {
+	c.stats.record(family, time.Since(start), result, err)
+}
+
+// lookupOutcome classifies a lookup that returned err
+func lookupOutcome(err error) outcome {
+	if err == nil {
+		return outcomeHit
+	}
+	return outcomeMiss
+}
+
+// IncrementRateLimit increments a rate limit counter and returns the new count
+func (c *instrumentedCache) IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int, error) {
+	start := time.Now()
+	count, err := c.inner.IncrementRateLimit(ctx, key, window)
+	c.observe(KeyFamily(RateLimitKeyPrefix), start, outcomeNone, err)
+	return count, err
+}
+
+// GetRateLimit gets the current rate limit count
+func (c *instrumentedCache) GetRateLimit(ctx context.Context, key string) (int, error) {
+	start := time.Now()
+	count, err := c.inner.GetRateLimit(ctx, key)
+	c.observe(KeyFamily(RateLimitKeyPrefix), start, outcomeNone, err)
+	return count, err
+}
+
+// ResetRateLimit resets a rate limit counter
+func (c *instrumentedCache) ResetRateLimit(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.inner.ResetRateLimit(ctx, key)
+	c.observe(KeyFamily(RateLimitKeyPrefix), start, outcomeNone, err)
+	return err
+}
+
+// TakeToken takes a token from a rate limit bucket
+func (c *instrumentedCache) TakeToken(ctx context.Context, key string, bucket TokenBucket) (TokenBucketResult, error) {
+	start := time.Now()
+	result, err := c.inner.TakeToken(ctx, key, bucket)
+	c.observe(KeyFamily(RateLimitKeyPrefix), start, outcomeNone, err)
+	return result, err
+}
+
+// Get retrieves a value from cache by key
+func (c *instrumentedCache) Get(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	val, err := c.inner.Get(ctx, key)
+	c.observe(KeyFamily(key), start, lookupOutcome(err), err)
+	return val, err
+}
+
+// Set stores a value in cache with TTL
+func (c *instrumentedCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	start := time.Now()
+	err := c.inner.Set(ctx, key, value, ttl)
+	c.observe(KeyFamily(key), start, outcomeNone, err)
+	return err
+}
+
+// Delete removes a key from cache
+func (c *instrumentedCache) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := c.inner.Delete(ctx, key)
+	c.observe(KeyFamily(key), start, outcomeNone, err)
+	return err
+}
+
+// CompareAndSwap replaces a value if it still holds the expected one
+func (c *instrumentedCache) CompareAndSwap(ctx context.Context, key, old, value string) (bool, error) {
+	start := time.Now()
+	swapped, err := c.inner.CompareAndSwap(ctx, key, old, value)
+	c.observe(KeyFamily(key), start, outcomeNone, err)
+	return swapped, err
+}
+
+// Exists checks if a key exists in cache
+// Counted as a lookup: negative cache checks use Exists
+func (c *instrumentedCache) Exists(ctx context.Context, key string) (bool, error) {
+	start := time.Now()
+	ok, err := c.inner.Exists(ctx, key)
+	result := outcomeMiss
+	if ok {
+		result = outcomeHit
+	}
+	c.observe(KeyFamily(key), start, result, err)
+	return ok, err
+}
+
+// TTL returns the remaining lifetime of a key
+// Not counted as a lookup so inspecting keys doesn't skew hit ratios
+func (c *instrumentedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	start := time.Now()
+	ttl, err := c.inner.TTL(ctx, key)
+	if err == ErrCacheMiss {
+		c.observe(KeyFamily(key), start, outcomeNone, nil)
+	} else {
+		c.observe(KeyFamily(key), start, outcomeNone, err)
+	}
+	return ttl, err
+}
+
+// DeletePrefix removes every unprotected key starting with prefix
+func (c *instrumentedCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	start := time.Now()
+	deleted, err := c.inner.DeletePrefix(ctx, prefix)
+	c.observe(KeyFamily(prefix), start, outcomeNone, err)
+	return deleted, err
+}
+
+// Ping checks if the underlying cache is reachable
+func (c *instrumentedCache) Ping(ctx context.Context) error {
+	return c.inner.Ping(ctx)
+}
@@ -0,0 +1,71 @@
+package cache
+
+import "strings"
+
+// protectedKeyPrefixes lists keys that bulk operations such as DeletePrefix never touch
+// Rate limit counters, IP rules and bans are shared enforcement state, not cached data
+var protectedKeyPrefixes = []string{RateLimitKeyPrefix, IPFilterKeyPrefix}
+
+// keyFamily maps a key prefix to the family name used in stats
+type keyFamily struct {
+	prefix string
+	family string
+}
+
+// keyFamilies lists the known key prefixes
+// Longer prefixes come first so the most specific family wins
+var keyFamilies = append(userKeyFamilies(CodecJSON, CodecMsgpack, CodecGob),
+	keyFamily{RateLimitKeyPrefix, "ratelimit"},
+	keyFamily{IPBanKeyPrefix, "ipfilter:ban"},
+	keyFamily{IPFilterKeyPrefix, "ipfilter"},
+)
+
+// userKeyFamilies lists the user key prefixes; keys of every codec share one family
+func userKeyFamilies(codecs ...string) []keyFamily {
+	families := []keyFamily{
+		{UserNotFoundIDKeyPrefix, "user:notfound:id"},
+		{UserNotFoundEmailKeyPrefix, "user:notfound:email"},
+	}
+	for _, codec := range codecs {
+		families = append(families,
+			keyFamily{UserIDKeyPrefix(codec), "user:id"},
+			keyFamily{UserEmailKeyPrefix(codec), "user:email"},
+		)
+	}
+	return families
+}
+
+// IsProtectedKey reports whether key must survive bulk deletes
+func IsProtectedKey(key string) bool {
+	for _, prefix := range protectedKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProtectedPrefix reports whether deleting by prefix could reach protected keys
+// True for the empty prefix, any prefix of a protected prefix (e.g. "rate") and anything inside one
+func IsProtectedPrefix(prefix string) bool {
+	for _, protected := range protectedKeyPrefixes {
+		if strings.HasPrefix(protected, prefix) || strings.HasPrefix(prefix, protected) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyFamily groups a key for stats, e.g. "user:v1:json:id:42" -> "user:id"
+// Unknown keys are grouped by their first segment
+func KeyFamily(key string) string {
+	for _, f := range keyFamilies {
+		if strings.HasPrefix(key, f.prefix) {
+			return f.family
+		}
+	}
+	if i := strings.IndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+	return "other"
+}
@@ -0,0 +1,50 @@
+package cache
+
+import "testing"
+
+func TestUserKeyPrefixesDifferByCodec(t *testing.T) {
+	seen := make(map[string]string)
+	for _, codec := range []string{CodecJSON, CodecMsgpack, CodecGob} {
+		for _, prefix := range []string{UserIDKeyPrefix(codec), UserEmailKeyPrefix(codec)} {
+			if other, ok := seen[prefix]; ok {
+				t.Fatalf("codecs %s and %s share key prefix %q", other, codec, prefix)
+			}
+			seen[prefix] = codec
+		}
+	}
+}
+
+func TestCodecNameMatchesRegistration(t *testing.T) {
+	for _, name := range []string{CodecJSON, CodecMsgpack, CodecGob} {
+		codec, err := NewCodec[CachedUser](name)
+		if err != nil {
+			t.Fatalf("NewCodec(%q): %v", name, err)
+		}
+		if got := NewUserCodec(codec).Name(); got != name {
+			t.Errorf("NewUserCodec(NewCodec(%q)).Name() = %q", name, got)
+		}
+	}
+}
+
+func TestKeyFamily(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"user:v1:json:id:42", "user:id"},
+		{"user:v1:msgpack:id:42", "user:id"},
+		{"user:v1:gob:email:a@example.com", "user:email"},
+		{"user:v1:notfound:id:42", "user:notfound:id"},
+		{"user:v1:notfound:email:a@example.com", "user:notfound:email"},
+		{"ratelimit:bucket:1.2.3.4", "ratelimit"},
+		{"ipfilter:ban:1.2.3.4", "ipfilter:ban"},
+		{"ipfilter:rules", "ipfilter"},
+		{"session:abc", "session"},
+		{"plain", "other"},
+	}
+	for _, tt := range tests {
+		if got := KeyFamily(tt.key); got != tt.want {
+			t.Errorf("KeyFamily(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
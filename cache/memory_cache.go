@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryCacheMaxEntries is the default size limit of the in-memory cache
+const DefaultMemoryCacheMaxEntries = 10000
+
+// memoryEntry is a single cached value
+type memoryEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero means no expiration
+}
+
+// expired reports whether the entry has passed its TTL
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// memoryCache implements Cache interface as a bounded in-process LRU cache
+// Used for single-instance deployments that want caching without Redis
+// Values are stored serialized (like Redis) so callers never share mutable state
+// Protected keys (rate limits, IP rules and bans) are enforcement state, not cached data:
+// they are kept on their own list, never evicted and don't count towards maxEntries
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // front is most recently used
+	protected  *list.List // protected keys, front is most recently used
+}
+
+// NewMemoryCache creates a new in-memory cache holding at most maxEntries keys
+// The least recently used key is evicted when the limit is reached
+func NewMemoryCache(maxEntries int) Cache {
+	if maxEntries < 1 {
+		maxEntries = DefaultMemoryCacheMaxEntries
+	}
+	return &memoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		protected:  list.New(),
+	}
+}
+
+// getLocked returns a live entry and marks it as recently used
+// Must be called with m.mu held
+func (m *memoryCache) getLocked(key string, now time.Time) (*memoryEntry, bool) {
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if entry.expired(now) {
+		m.removeLocked(elem)
+		return nil, false
+	}
+	m.listFor(key).MoveToFront(elem)
+	return entry, true
+}
+
+// listFor returns the recency list holding key
+func (m *memoryCache) listFor(key string) *list.List {
+	if IsProtectedKey(key) {
+		return m.protected
+	}
+	return m.lru
+}
+
+// setLocked stores a value, evicting the least recently used entry if the cache is full
+// Protected keys are never evicted; expired ones are swept instead so they cannot pile up
+// Must be called with m.mu held
+func (m *memoryCache) setLocked(key, value string, ttl time.Duration, now time.Time) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.listFor(key).MoveToFront(elem)
+		return
+	}
+
+	m.entries[key] = m.listFor(key).PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	if IsProtectedKey(key) {
+		m.sweepProtectedLocked(now)
+		return
+	}
+	for m.lru.Len() > m.maxEntries {
+		m.removeLocked(m.lru.Back())
+	}
+}
+
+// protectedSweepBatch is how many of the least recently used protected keys each write checks for expiry
+const protectedSweepBatch = 8
+
+// sweepProtectedLocked removes expired entries among the least recently used protected keys
+// Must be called with m.mu held
+func (m *memoryCache) sweepProtectedLocked(now time.Time) {
+	elem := m.protected.Back()
+	for i := 0; i < protectedSweepBatch && elem != nil; i++ {
+		prev := elem.Prev()
+		if elem.Value.(*memoryEntry).expired(now) {
+			m.removeLocked(elem)
+		}
+		elem = prev
+	}
+}
+
+// removeLocked deletes an entry
+// Must be called with m.mu held
+func (m *memoryCache) removeLocked(elem *list.Element) {
+	key := elem.Value.(*memoryEntry).key
+	m.listFor(key).Remove(elem)
+	delete(m.entries, key)
+}
+
+// purge removes every entry
+func (m *memoryCache) purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+	m.protected.Init()
+}
+
+// IncrementRateLimit increments a rate limit counter and returns the new count
+// The increment and the expiration are applied atomically under the cache lock
+func (m *memoryCache) IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int, error) {
+	rateLimitKey := RateLimitKeyPrefix + key
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.getLocked(rateLimitKey, now)
+	if !ok {
+		m.setLocked(rateLimitKey, "1", window, now)
+		return 1, nil
+	}
+
+	count, err := strconv.Atoi(entry.value)
+	if err != nil {
+		return 0, fmt.Errorf("rate limit counter %s is not an integer: %w", rateLimitKey, err)
+	}
+	count++
+	entry.value = strconv.Itoa(count)
+	return count, nil
+}
+
+// GetRateLimit gets the current rate limit count
+func (m *memoryCache) GetRateLimit(ctx context.Context, key string) (int, error) {
+	val, err := m.Get(ctx, RateLimitKeyPrefix+key)
+	if err != nil {
+		if err == ErrCacheMiss {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(val)
+}
+
+// ResetRateLimit resets a rate limit counter
+func (m *memoryCache) ResetRateLimit(ctx context.Context, key string) error {
+	return m.Delete(ctx, RateLimitKeyPrefix+key)
+}
+
+// TakeToken takes a token from a bucket, refilling it first
+// Refill and take happen atomically under the cache lock
+func (m *memoryCache) TakeToken(ctx context.Context, key string, bucket TokenBucket) (TokenBucketResult, error) {
+	bucketKey := RateLimitBucketKeyPrefix + key
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var state bucketState
+	if entry, ok := m.getLocked(bucketKey, now); ok {
+		var err error
+		if state, err = decodeBucketState(entry.value); err != nil {
+			return TokenBucketResult{}, err
+		}
+	}
+
+	result := bucket.take(&state, now)
+	// A full bucket is indistinguishable from a missing one, so the state can expire then
+	m.setLocked(bucketKey, state.encode(), result.ResetAfter+time.Second, now)
+	return result, nil
+}
+
+// Get retrieves a value from cache by key
+func (m *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.getLocked(key, time.Now())
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+// Set stores a value in cache with TTL (0 means no expiration)
+func (m *memoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setLocked(key, value, ttl, time.Now())
+	return nil
+}
+
+// Delete removes a key from cache
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.removeLocked(elem)
+	}
+	return nil
+}
+
+// CompareAndSwap replaces a value under the cache lock
+func (m *memoryCache) CompareAndSwap(ctx context.Context, key, old, value string) (bool, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := ""
+	if entry, ok := m.getLocked(key, now); ok {
+		current = entry.value
+	}
+	if current != old {
+		return false, nil
+	}
+	m.setLocked(key, value, 0, now)
+	return true, nil
+}
+
+// Exists checks if a key exists in cache
+func (m *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.getLocked(key, time.Now())
+	return ok, nil
+}
+
+// TTL returns the remaining lifetime of a key without marking it as recently used
+func (m *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	entry := elem.Value.(*memoryEntry)
+	now := time.Now()
+	if entry.expired(now) {
+		m.removeLocked(elem)
+		return 0, ErrCacheMiss
+	}
+	if entry.expiresAt.IsZero() {
+		return NoExpiration, nil
+	}
+	return entry.expiresAt.Sub(now), nil
+}
+
+// DeletePrefix removes every unprotected key starting with prefix
+func (m *memoryCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for key, elem := range m.entries {
+		if strings.HasPrefix(key, prefix) && !IsProtectedKey(key) {
+			m.removeLocked(elem)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Ping always succeeds (in-process cache)
+func (m *memoryCache) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		writes     []string
+		wantKept   []string
+		wantGone   []string
+		maxEntries int
+	}{
+		{
+			name:       "least recently used entry is evicted",
+			maxEntries: 2,
+			writes:     []string{"a", "b", "c"},
+			wantKept:   []string{"b", "c"},
+			wantGone:   []string{"a"},
+		},
+		{
+			name:       "protected keys are never evicted",
+			maxEntries: 2,
+			writes:     []string{IPRulesKey, IPBanKeyPrefix + "203.0.113.7", RateLimitBucketKeyPrefix + "203.0.113.7", "a", "b", "c"},
+			wantKept:   []string{IPRulesKey, IPBanKeyPrefix + "203.0.113.7", RateLimitBucketKeyPrefix + "203.0.113.7", "b", "c"},
+			wantGone:   []string{"a"},
+		},
+		{
+			name:       "protected keys do not count towards the limit",
+			maxEntries: 1,
+			writes:     []string{"a", IPRulesKey, RateLimitKeyPrefix + "x"},
+			wantKept:   []string{"a", IPRulesKey, RateLimitKeyPrefix + "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewMemoryCache(tt.maxEntries)
+			for _, key := range tt.writes {
+				if err := c.Set(ctx, key, "value", 0); err != nil {
+					t.Fatalf("Set(%q): %v", key, err)
+				}
+			}
+			for _, key := range tt.wantKept {
+				if ok, _ := c.Exists(ctx, key); !ok {
+					t.Errorf("%q was evicted", key)
+				}
+			}
+			for _, key := range tt.wantGone {
+				if ok, _ := c.Exists(ctx, key); ok {
+					t.Errorf("%q was kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryCacheSweepsExpiredProtectedKeys(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10).(*memoryCache)
+	for i := 0; i < protectedSweepBatch; i++ {
+		c.Set(ctx, fmt.Sprintf("%sold%d", RateLimitKeyPrefix, i), "1", time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	c.Set(ctx, RateLimitKeyPrefix+"new", "1", time.Minute)
+
+	if got := c.protected.Len(); got != 1 {
+		t.Errorf("protected entries after sweep = %d, want 1", got)
+	}
+	if got := len(c.entries); got != 1 {
+		t.Errorf("entries after sweep = %d, want 1", got)
+	}
+}
+
+func TestMemoryCacheCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, func() Cache { return NewMemoryCache(10) }, "key")
+}
+
+// TestRedisCompareAndSwap runs the Lua script through the same cases as the memory cache
+// Needs a Redis server; set REDIS_HOST (and optionally REDIS_PORT) to run it
+func TestRedisCompareAndSwap(t *testing.T) {
+	client := testRedisClient(t)
+	key := fmt.Sprintf("test:cas:%d", time.Now().UnixNano())
+	newCache := func() Cache {
+		client.Del(context.Background(), key)
+		return NewRedisCache(client)
+	}
+	defer client.Del(context.Background(), key)
+	testCompareAndSwap(t, newCache, key)
+}
+
+// testCompareAndSwap checks CompareAndSwap on key in a fresh cache for every case
+func testCompareAndSwap(t *testing.T, newCache func() Cache, key string) {
+	tests := []struct {
+		name        string
+		stored      string // "" leaves the key absent
+		old         string
+		wantSwapped bool
+		wantValue   string
+	}{
+		{"absent key, expected absent", "", "", true, "new"},
+		{"absent key, expected a value", "", "current", false, ""},
+		{"matching value", "current", "current", true, "new"},
+		{"changed value", "other", "current", false, "other"},
+		{"present key, expected absent", "current", "", false, "current"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newCache()
+			if tt.stored != "" {
+				c.Set(ctx, key, tt.stored, 0)
+			}
+			swapped, err := c.CompareAndSwap(ctx, key, tt.old, "new")
+			if err != nil || swapped != tt.wantSwapped {
+				t.Fatalf("CompareAndSwap = %v, %v, want %v", swapped, err, tt.wantSwapped)
+			}
+			got, _ := c.Get(ctx, key)
+			if got != tt.wantValue {
+				t.Errorf("value = %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
@@ -3,8 +3,6 @@ package cache
 import (
 	"context"
 	"time"
-
-	"github.com/leventeberry/goapi/models"
 )
 
 // noOpCache implements Cache interface as a no-op (no operation) cache
@@ -16,41 +14,6 @@ func NewNoOpCache() Cache {
 	return &noOpCache{}
 }
 
-// GetUserByID always returns cache miss
-func (n *noOpCache) GetUserByID(ctx context.Context, id int) (*models.User, error) {
-	return nil, ErrCacheMiss
-}
-
-// SetUserByID does nothing
-func (n *noOpCache) SetUserByID(ctx context.Context, id int, user *models.User, ttl time.Duration) error {
-	return nil
-}
-
-// GetUserByEmail always returns cache miss
-func (n *noOpCache) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	return nil, ErrCacheMiss
-}
-
-// SetUserByEmail does nothing
-func (n *noOpCache) SetUserByEmail(ctx context.Context, email string, user *models.User, ttl time.Duration) error {
-	return nil
-}
-
-// DeleteUserByID does nothing
-func (n *noOpCache) DeleteUserByID(ctx context.Context, id int) error {
-	return nil
-}
-
-// DeleteUserByEmail does nothing
-func (n *noOpCache) DeleteUserByEmail(ctx context.Context, email string) error {
-	return nil
-}
-
-// DeleteUser does nothing
-func (n *noOpCache) DeleteUser(ctx context.Context, id int, email string) error {
-	return nil
-}
-
 // IncrementRateLimit always returns 0
 func (n *noOpCache) IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int, error) {
 	return 0, nil
@@ -66,6 +29,11 @@ func (n *noOpCache) ResetRateLimit(ctx context.Context, key string) error {
 	return nil
 }
 
+// TakeToken always allows the request
+func (n *noOpCache) TakeToken(ctx context.Context, key string, bucket TokenBucket) (TokenBucketResult, error) {
+	return TokenBucketResult{Allowed: true, Remaining: bucket.Burst}, nil
+}
+
 // Get always returns cache miss
 func (n *noOpCache) Get(ctx context.Context, key string) (string, error) {
 	return "", ErrCacheMiss
@@ -81,6 +49,11 @@ func (n *noOpCache) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// CompareAndSwap does nothing and reports success, like Set
+func (n *noOpCache) CompareAndSwap(ctx context.Context, key, old, value string) (bool, error) {
+	return true, nil
+}
+
 // Exists always returns false
 func (n *noOpCache) Exists(ctx context.Context, key string) (bool, error) {
 	return false, nil
@@ -91,3 +64,12 @@ func (n *noOpCache) Ping(ctx context.Context) error {
 	return nil
 }
 
+// TTL always returns cache miss
+func (n *noOpCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, ErrCacheMiss
+}
+
+// DeletePrefix does nothing
+func (n *noOpCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	return 0, nil
+}
@@ -2,18 +2,73 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/leventeberry/goapi/models"
 	"github.com/redis/go-redis/v9"
 )
 
-// User cache methods use the following key patterns:
-// - GetUserByID / SetUserByID: "user:id:{id}"
-// - GetUserByEmail / SetUserByEmail: "user:email:{email}"
-// - DeleteUser: deletes both ID and email keys for a user
+// scanBatchSize is the COUNT hint used when scanning keys
+const scanBatchSize = 500
+
+// globEscaper escapes Redis glob metacharacters so a prefix is matched literally
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// incrementScript increments a counter and sets its expiration on first use
+// KEYS[1] counter key, ARGV[1] window in milliseconds
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// tokenBucketScript refills a token bucket and takes one token (see TokenBucket.take)
+// KEYS[1] bucket key, ARGV[1] refill rate in tokens per second, ARGV[2] burst
+// Returns {allowed, remaining, retry_after_ms, reset_after_ms}
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+  tokens = burst
+elseif now > updated then
+  tokens = math.min(burst, tokens + (now - updated) * rate)
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry_after = math.ceil((1 - tokens) / rate * 1000)
+end
+local reset_after = math.ceil((burst - tokens) / rate * 1000)
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+-- A full bucket is indistinguishable from a missing one, so the state can expire then
+redis.call('PEXPIRE', KEYS[1], reset_after + 1000)
+return {allowed, math.floor(tokens), retry_after, reset_after}
+`)
+
+// compareAndSwapScript replaces a value only if it still holds the expected one
+// KEYS[1] key, ARGV[1] expected value ("" for an absent key), ARGV[2] new value
+var compareAndSwapScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if (current == false and ARGV[1] == '') or current == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[2])
+  return 1
+end
+return 0
+`)
 
 // redisCache implements Cache interface using Redis
 type redisCache struct {
@@ -30,100 +85,20 @@ func NewRedisCache(client *redis.Client) Cache {
 	}
 }
 
-// GetUserByID retrieves a user from cache by ID
-func (r *redisCache) GetUserByID(ctx context.Context, id int) (*models.User, error) {
-	key := fmt.Sprintf("user:id:%d", id)
-	val, err := r.client.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, ErrCacheMiss
-		}
-		return nil, err
-	}
-
-	var user models.User
-	if err := json.Unmarshal([]byte(val), &user); err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
-// SetUserByID stores a user in cache by ID
-func (r *redisCache) SetUserByID(ctx context.Context, id int, user *models.User, ttl time.Duration) error {
-	key := fmt.Sprintf("user:id:%d", id)
-	data, err := json.Marshal(user)
-	if err != nil {
-		return err
-	}
-	return r.client.Set(ctx, key, data, ttl).Err()
-}
-
-// GetUserByEmail retrieves a user from cache by email
-func (r *redisCache) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	key := fmt.Sprintf("user:email:%s", email)
-	val, err := r.client.Get(ctx, key).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, ErrCacheMiss
-		}
-		return nil, err
-	}
-
-	var user models.User
-	if err := json.Unmarshal([]byte(val), &user); err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
-// SetUserByEmail stores a user in cache by email
-func (r *redisCache) SetUserByEmail(ctx context.Context, email string, user *models.User, ttl time.Duration) error {
-	key := fmt.Sprintf("user:email:%s", email)
-	data, err := json.Marshal(user)
-	if err != nil {
-		return err
-	}
-	return r.client.Set(ctx, key, data, ttl).Err()
-}
-
-// DeleteUserByID deletes a user from cache by ID
-func (r *redisCache) DeleteUserByID(ctx context.Context, id int) error {
-	key := fmt.Sprintf("user:id:%d", id)
-	return r.client.Del(ctx, key).Err()
-}
-
-// DeleteUserByEmail deletes a user from cache by email
-func (r *redisCache) DeleteUserByEmail(ctx context.Context, email string) error {
-	key := fmt.Sprintf("user:email:%s", email)
-	return r.client.Del(ctx, key).Err()
-}
-
-// DeleteUser deletes both ID and email keys for a user
-func (r *redisCache) DeleteUser(ctx context.Context, id int, email string) error {
-	idKey := fmt.Sprintf("user:id:%d", id)
-	emailKey := fmt.Sprintf("user:email:%s", email)
-	return r.client.Del(ctx, idKey, emailKey).Err()
-}
-
 // IncrementRateLimit increments a rate limit counter and returns the new count
+// The increment and the expiration run in one script so a key can never be left without a TTL
 func (r *redisCache) IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int, error) {
-	rateLimitKey := fmt.Sprintf("ratelimit:%s", key)
-	count, err := r.client.Incr(ctx, rateLimitKey).Result()
+	rateLimitKey := RateLimitKeyPrefix + key
+	count, err := incrementScript.Run(ctx, r.client, []string{rateLimitKey}, window.Milliseconds()).Int64()
 	if err != nil {
 		return 0, err
 	}
-	
-	// Set expiration on first increment
-	if count == 1 {
-		r.client.Expire(ctx, rateLimitKey, window)
-	}
-	
 	return int(count), nil
 }
 
 // GetRateLimit gets the current rate limit count
 func (r *redisCache) GetRateLimit(ctx context.Context, key string) (int, error) {
-	rateLimitKey := fmt.Sprintf("ratelimit:%s", key)
+	rateLimitKey := RateLimitKeyPrefix + key
 	count, err := r.client.Get(ctx, rateLimitKey).Int64()
 	if err != nil {
 		if err == redis.Nil {
@@ -136,10 +111,39 @@ func (r *redisCache) GetRateLimit(ctx context.Context, key string) (int, error)
 
 // ResetRateLimit resets a rate limit counter
 func (r *redisCache) ResetRateLimit(ctx context.Context, key string) error {
-	rateLimitKey := fmt.Sprintf("ratelimit:%s", key)
+	rateLimitKey := RateLimitKeyPrefix + key
 	return r.client.Del(ctx, rateLimitKey).Err()
 }
 
+// TakeToken takes a token from a bucket, refilling it first
+// Runs as a single Lua script so concurrent requests from every instance see a consistent bucket
+// Uses the Redis server clock so instances with skewed clocks agree on refill timing
+func (r *redisCache) TakeToken(ctx context.Context, key string, bucket TokenBucket) (TokenBucketResult, error) {
+	bucketKey := RateLimitBucketKeyPrefix + key
+	values, err := tokenBucketScript.Run(ctx, r.client, []string{bucketKey}, bucket.Rate, bucket.Burst).Int64Slice()
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+	if len(values) != 4 {
+		return TokenBucketResult{}, fmt.Errorf("token bucket script returned %d values, want 4", len(values))
+	}
+	return TokenBucketResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// CompareAndSwap replaces a value in a single Lua script so writers on every instance are serialized
+func (r *redisCache) CompareAndSwap(ctx context.Context, key, old, value string) (bool, error) {
+	swapped, err := compareAndSwapScript.Run(ctx, r.client, []string{key}, old, value).Int64()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
 // Get retrieves a value from cache by key
 func (r *redisCache) Get(ctx context.Context, key string) (string, error) {
 	val, err := r.client.Get(ctx, key).Result()
@@ -171,6 +175,55 @@ func (r *redisCache) Exists(ctx context.Context, key string) (bool, error) {
 	return count > 0, nil
 }
 
+// TTL returns the remaining lifetime of a key
+func (r *redisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis reports -2 for missing keys and -1 for keys without an expiration
+	switch ttl {
+	case -2:
+		return 0, ErrCacheMiss
+	case -1:
+		return NoExpiration, nil
+	}
+	return ttl, nil
+}
+
+// DeletePrefix removes every unprotected key starting with prefix
+// Uses SCAN rather than KEYS so Redis is never blocked, and UNLINK to free memory in the background
+func (r *redisCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	pattern := globEscaper.Replace(prefix) + "*"
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		unprotected := keys[:0]
+		for _, key := range keys {
+			if !IsProtectedKey(key) {
+				unprotected = append(unprotected, key)
+			}
+		}
+		if len(unprotected) > 0 {
+			n, err := r.client.Unlink(ctx, unprotected...).Result()
+			deleted += int(n)
+			if err != nil {
+				return deleted, err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
 // Ping checks if Redis connection is alive
 func (r *redisCache) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
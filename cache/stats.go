@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// outcome classifies a cache operation for stats
+type outcome int
+
+const (
+	outcomeNone outcome = iota // writes, deletes and inspection
+	outcomeHit
+	outcomeMiss
+)
+
+// familyCounters holds the counters of one key family
+type familyCounters struct {
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	errors     atomic.Uint64
+	operations atomic.Uint64
+	latency    atomic.Int64 // total nanoseconds
+	maxLatency atomic.Int64 // nanoseconds
+}
+
+// FamilyStats is a point-in-time view of the counters of one key family
+type FamilyStats struct {
+	Family       string  `json:"family"`
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	Errors       uint64  `json:"errors"`
+	Operations   uint64  `json:"operations"`
+	HitRatio     float64 `json:"hit_ratio"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// Stats collects hit/miss/error/latency counters per key family
+// Safe for concurrent use; counters are cumulative since process start
+type Stats struct {
+	mu       sync.RWMutex
+	families map[string]*familyCounters
+}
+
+// NewStats creates an empty stats collector
+func NewStats() *Stats {
+	return &Stats{families: make(map[string]*familyCounters)}
+}
+
+// counters returns the counters of a family, creating them on first use
+func (s *Stats) counters(family string) *familyCounters {
+	s.mu.RLock()
+	c, ok := s.families[family]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.families[family]; !ok {
+		c = &familyCounters{}
+		s.families[family] = c
+	}
+	return c
+}
+
+// record counts one operation
+// ErrCacheMiss is a miss, not an error
+func (s *Stats) record(family string, elapsed time.Duration, result outcome, err error) {
+	c := s.counters(family)
+	c.operations.Add(1)
+	c.latency.Add(int64(elapsed))
+	for {
+		max := c.maxLatency.Load()
+		if int64(elapsed) <= max || c.maxLatency.CompareAndSwap(max, int64(elapsed)) {
+			break
+		}
+	}
+
+	switch {
+	case err == ErrCacheMiss:
+		c.misses.Add(1)
+	case err != nil:
+		c.errors.Add(1)
+	case result == outcomeHit:
+		c.hits.Add(1)
+	case result == outcomeMiss:
+		c.misses.Add(1)
+	}
+}
+
+// Snapshot returns the current counters of every family, sorted by family name
+func (s *Stats) Snapshot() []FamilyStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make([]FamilyStats, 0, len(s.families))
+	for family, c := range s.families {
+		stats := FamilyStats{
+			Family:       family,
+			Hits:         c.hits.Load(),
+			Misses:       c.misses.Load(),
+			Errors:       c.errors.Load(),
+			Operations:   c.operations.Load(),
+			MaxLatencyMs: durationMs(time.Duration(c.maxLatency.Load())),
+		}
+		if lookups := stats.Hits + stats.Misses; lookups > 0 {
+			stats.HitRatio = float64(stats.Hits) / float64(lookups)
+		}
+		if stats.Operations > 0 {
+			stats.AvgLatencyMs = durationMs(time.Duration(c.latency.Load() / int64(stats.Operations)))
+		}
+		snapshot = append(snapshot, stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Family < snapshot[j].Family })
+	return snapshot
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Tiered cache settings
+const (
+	// InvalidationChannel is the Redis pub/sub channel used to broadcast L1 evictions
+	InvalidationChannel = "cache:invalidate"
+
+	// DefaultL1TTL is the default lifetime of entries in the local L1 cache
+	// Kept short because another instance may update the value in L2 at any time
+	DefaultL1TTL = 30 * time.Second
+
+	// l1KeyPrefix selects the keys held in L1; rate limit counters and other shared state always go to L2
+	l1KeyPrefix = UserKeyPrefix
+
+	// pubSubHealthInterval is how long the subscriber waits for a message before pinging the connection
+	pubSubHealthInterval = 30 * time.Second
+
+	// pubSubRetryDelay is the pause before resubscribing after a pub/sub failure
+	pubSubRetryDelay = time.Second
+)
+
+// invalidationMessage is published on InvalidationChannel when keys are deleted
+type invalidationMessage struct {
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// tieredCache implements Cache interface with a local in-process L1 in front of Redis (L2)
+// User entries are served from L1 for up to the L1 TTL, saving a network round trip
+// Deletions are broadcast over Redis pub/sub so every instance evicts its L1 copy
+// While the pub/sub subscription is down, L1 is bypassed so no instance serves stale data
+type tieredCache struct {
+	l1       *memoryCache
+	l2       Cache
+	client   *redis.Client
+	pubsub   *redis.PubSub
+	l1TTL    time.Duration
+	origin   string
+	healthy  atomic.Bool
+	cancel   context.CancelFunc
+	finished chan struct{}
+}
+
+// NewTieredCache creates a two-tier cache over the given Redis client
+// l1MaxEntries bounds the local cache and l1TTL caps how long an entry lives there
+func NewTieredCache(client *redis.Client, l1MaxEntries int, l1TTL time.Duration) Cache {
+	if client == nil {
+		return NewNoOpCache()
+	}
+	if l1TTL <= 0 {
+		l1TTL = DefaultL1TTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &tieredCache{
+		l1:       NewMemoryCache(l1MaxEntries).(*memoryCache),
+		l2:       NewRedisCache(client),
+		client:   client,
+		pubsub:   client.Subscribe(ctx, InvalidationChannel),
+		l1TTL:    l1TTL,
+		origin:   uuid.NewString(),
+		cancel:   cancel,
+		finished: make(chan struct{}),
+	}
+	go t.subscribe(ctx)
+	return t
+}
+
+// Close stops the invalidation subscriber
+func (t *tieredCache) Close() error {
+	t.cancel()
+	err := t.pubsub.Close()
+	<-t.finished
+	return err
+}
+
+// subscribe consumes invalidation messages until the cache is closed
+// go-redis resubscribes automatically on the next receive after a connection error
+func (t *tieredCache) subscribe(ctx context.Context) {
+	defer close(t.finished)
+
+	for {
+		msg, err := t.pubsub.ReceiveTimeout(ctx, pubSubHealthInterval)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			// No traffic for a while - make sure the connection is still alive
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err = t.pubsub.Ping(ctx); err == nil {
+					continue
+				}
+			}
+			t.setHealthy(false)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pubSubRetryDelay):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// (Re)subscribed - invalidations may have been missed while we were away
+			t.setHealthy(true)
+		case *redis.Message:
+			t.applyInvalidation(msg.Payload)
+		}
+	}
+}
+
+// setHealthy records the subscription state
+// L1 is cleared on every transition since invalidations may have been lost
+func (t *tieredCache) setHealthy(healthy bool) {
+	if !healthy {
+		t.healthy.Store(false)
+	}
+	t.l1.purge()
+	if healthy {
+		t.healthy.Store(true)
+	}
+}
+
+// applyInvalidation evicts the keys named in a message published by another instance
+func (t *tieredCache) applyInvalidation(payload string) {
+	var msg invalidationMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		// Unknown message format - evict everything to stay safe
+		t.l1.purge()
+		return
+	}
+	if msg.Origin == t.origin {
+		return
+	}
+	for _, key := range msg.Keys {
+		t.l1.Delete(context.Background(), key)
+	}
+	for _, prefix := range msg.Prefixes {
+		t.l1.DeletePrefix(context.Background(), prefix)
+	}
+}
+
+// publishInvalidation tells other instances to evict keys from their L1
+func (t *tieredCache) publishInvalidation(ctx context.Context, keys ...string) error {
+	return t.publish(ctx, invalidationMessage{Origin: t.origin, Keys: keys})
+}
+
+// publish broadcasts an invalidation message
+func (t *tieredCache) publish(ctx context.Context, msg invalidationMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return t.client.Publish(ctx, InvalidationChannel, data).Err()
+}
+
+// useL1 reports whether key may be served from the local cache right now
+func (t *tieredCache) useL1(key string) bool {
+	return strings.HasPrefix(key, l1KeyPrefix) && t.healthy.Load()
+}
+
+// l1TTLFor caps an L2 TTL to the L1 TTL
+func (t *tieredCache) l1TTLFor(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > t.l1TTL {
+		return t.l1TTL
+	}
+	return ttl
+}
+
+// IncrementRateLimit increments a rate limit counter in L2
+func (t *tieredCache) IncrementRateLimit(ctx context.Context, key string, window time.Duration) (int, error) {
+	return t.l2.IncrementRateLimit(ctx, key, window)
+}
+
+// GetRateLimit gets the current rate limit count from L2
+func (t *tieredCache) GetRateLimit(ctx context.Context, key string) (int, error) {
+	return t.l2.GetRateLimit(ctx, key)
+}
+
+// ResetRateLimit resets a rate limit counter in L2
+func (t *tieredCache) ResetRateLimit(ctx context.Context, key string) error {
+	return t.l2.ResetRateLimit(ctx, key)
+}
+
+// TakeToken takes a token from a bucket in L2
+func (t *tieredCache) TakeToken(ctx context.Context, key string, bucket TokenBucket) (TokenBucketResult, error) {
+	return t.l2.TakeToken(ctx, key, bucket)
+}
+
+// Get retrieves a value, checking L1 before L2 and filling L1 on an L2 hit
+func (t *tieredCache) Get(ctx context.Context, key string) (string, error) {
+	if !t.useL1(key) {
+		return t.l2.Get(ctx, key)
+	}
+	if val, err := t.l1.Get(ctx, key); err == nil {
+		return val, nil
+	}
+
+	val, err := t.l2.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	// Re-check: the subscription may have dropped while we were reading L2
+	if t.useL1(key) {
+		t.l1.Set(ctx, key, val, t.l1TTL)
+	}
+	return val, nil
+}
+
+// Set stores a value in L2 and, for L1 keys, in the local cache
+func (t *tieredCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := t.l2.Set(ctx, key, value, ttl); err != nil {
+		t.l1.Delete(ctx, key)
+		return err
+	}
+	if t.useL1(key) {
+		t.l1.Set(ctx, key, value, t.l1TTLFor(ttl))
+	}
+	return nil
+}
+
+// Delete removes a key from both tiers and broadcasts the eviction for L1 keys
+func (t *tieredCache) Delete(ctx context.Context, key string) error {
+	t.l1.Delete(ctx, key)
+	if err := t.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	if strings.HasPrefix(key, l1KeyPrefix) {
+		return t.publishInvalidation(ctx, key)
+	}
+	return nil
+}
+
+// CompareAndSwap replaces a value in L2 and evicts it from every L1
+func (t *tieredCache) CompareAndSwap(ctx context.Context, key, old, value string) (bool, error) {
+	t.l1.Delete(ctx, key)
+	swapped, err := t.l2.CompareAndSwap(ctx, key, old, value)
+	if err != nil || !swapped {
+		return swapped, err
+	}
+	if strings.HasPrefix(key, l1KeyPrefix) {
+		return true, t.publishInvalidation(ctx, key)
+	}
+	return true, nil
+}
+
+// Exists checks if a key exists in L1 or L2
+func (t *tieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if t.useL1(key) {
+		if ok, _ := t.l1.Exists(ctx, key); ok {
+			return true, nil
+		}
+	}
+	return t.l2.Exists(ctx, key)
+}
+
+// TTL returns the remaining lifetime of a key in L2
+func (t *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.l2.TTL(ctx, key)
+}
+
+// DeletePrefix removes matching keys from both tiers and broadcasts the eviction
+func (t *tieredCache) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	t.l1.DeletePrefix(ctx, prefix)
+	deleted, err := t.l2.DeletePrefix(ctx, prefix)
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, t.publish(ctx, invalidationMessage{Origin: t.origin, Prefixes: []string{prefix}})
+}
+
+// Ping checks if Redis (L2) is reachable
+func (t *tieredCache) Ping(ctx context.Context) error {
+	return t.l2.Ping(ctx)
+}
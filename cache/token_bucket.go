@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TokenBucket describes a token bucket rate limit
+// The bucket holds at most Burst tokens and refills continuously at Rate tokens per second
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a bucket refilling requestsPerMinute tokens per minute
+func PerMinute(requestsPerMinute, burst int) TokenBucket {
+	return TokenBucket{Rate: float64(requestsPerMinute) / 60, Burst: burst}
+}
+
+// TokenBucketResult is the outcome of taking a token
+type TokenBucketResult struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // time until the next token is available, zero if allowed
+	ResetAfter time.Duration // time until the bucket is full again
+}
+
+// bucketState is the persisted state of one bucket
+type bucketState struct {
+	tokens  float64
+	updated time.Time
+}
+
+// LocalBucket is one token bucket kept in process memory
+// It uses the same refill math as TakeToken, so in-process and cache-backed limiters agree
+// Not safe for concurrent use; callers serialize access
+type LocalBucket struct {
+	state bucketState
+}
+
+// Take refills the bucket up to now and tries to remove one token
+func (l *LocalBucket) Take(bucket TokenBucket, now time.Time) TokenBucketResult {
+	return bucket.take(&l.state, now)
+}
+
+// LastUsed returns when a token was last requested, zero for a new bucket
+func (l *LocalBucket) LastUsed() time.Time {
+	return l.state.updated
+}
+
+// take refills the bucket up to now and tries to remove one token
+// Mirrors the Lua script used by the Redis backend so both backends behave identically
+func (b TokenBucket) take(state *bucketState, now time.Time) TokenBucketResult {
+	burst := float64(b.Burst)
+	if state.updated.IsZero() {
+		state.tokens = burst
+	} else if elapsed := now.Sub(state.updated).Seconds(); elapsed > 0 {
+		state.tokens = math.Min(burst, state.tokens+elapsed*b.Rate)
+	}
+	state.updated = now
+
+	var result TokenBucketResult
+	if state.tokens >= 1 {
+		state.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - state.tokens) / b.Rate)
+	}
+	result.Remaining = int(state.tokens)
+	result.ResetAfter = secondsToDuration((burst - state.tokens) / b.Rate)
+	return result
+}
+
+// secondsToDuration converts fractional seconds to a duration rounded up to the millisecond
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds*1000)) * time.Millisecond
+}
+
+// encode serializes the state for string-valued caches
+func (s bucketState) encode() string {
+	return strconv.FormatFloat(s.tokens, 'f', -1, 64) + " " + strconv.FormatInt(s.updated.UnixMicro(), 10)
+}
+
+// decodeBucketState parses a state written by encode
+func decodeBucketState(value string) (bucketState, error) {
+	tokensStr, updatedStr, ok := strings.Cut(value, " ")
+	if !ok {
+		return bucketState{}, fmt.Errorf("malformed token bucket state %q", value)
+	}
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return bucketState{}, fmt.Errorf("malformed token bucket state %q: %w", value, err)
+	}
+	updated, err := strconv.ParseInt(updatedStr, 10, 64)
+	if err != nil {
+		return bucketState{}, fmt.Errorf("malformed token bucket state %q: %w", value, err)
+	}
+	return bucketState{tokens: tokens, updated: time.UnixMicro(updated)}, nil
+}
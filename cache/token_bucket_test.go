@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bucketStep is one request made offset after the start of a sequence
+type bucketStep struct {
+	offset    time.Duration
+	allowed   bool
+	remaining int
+}
+
+var bucketSequences = []struct {
+	name   string
+	bucket TokenBucket
+	steps  []bucketStep
+}{
+	{
+		name:   "burst then denied",
+		bucket: TokenBucket{Rate: 1, Burst: 3},
+		steps: []bucketStep{
+			{0, true, 2},
+			{0, true, 1},
+			{0, true, 0},
+			{0, false, 0},
+		},
+	},
+	{
+		name:   "refills one token per second",
+		bucket: TokenBucket{Rate: 1, Burst: 2},
+		steps: []bucketStep{
+			{0, true, 1},
+			{0, true, 0},
+			{500 * time.Millisecond, false, 0},
+			{time.Second, true, 0},
+			{3 * time.Second, true, 1},
+		},
+	},
+	{
+		// An integer refill would drop the half token earned before 1.5s and deny the request at 2s
+		name:   "keeps fractional refill progress",
+		bucket: TokenBucket{Rate: 1, Burst: 2},
+		steps: []bucketStep{
+			{0, true, 1},
+			{0, true, 0},
+			{1500 * time.Millisecond, true, 0},
+			{2 * time.Second, true, 0},
+			{2500 * time.Millisecond, false, 0},
+		},
+	},
+	{
+		name:   "denied requests do not reset refill",
+		bucket: TokenBucket{Rate: 1, Burst: 1},
+		steps: []bucketStep{
+			{0, true, 0},
+			{300 * time.Millisecond, false, 0},
+			{600 * time.Millisecond, false, 0},
+			{time.Second, true, 0},
+		},
+	},
+	{
+		name:   "refill is capped at burst",
+		bucket: PerMinute(60, 2),
+		steps: []bucketStep{
+			{0, true, 1},
+			{time.Hour, true, 1},
+			{time.Hour, true, 0},
+			{time.Hour, false, 0},
+		},
+	},
+}
+
+func TestTokenBucketTake(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	for _, tt := range bucketSequences {
+		t.Run(tt.name, func(t *testing.T) {
+			var state bucketState
+			for i, step := range tt.steps {
+				got := tt.bucket.take(&state, start.Add(step.offset))
+				if got.Allowed != step.allowed || got.Remaining != step.remaining {
+					t.Fatalf("step %d at %v: allowed=%v remaining=%d, want allowed=%v remaining=%d",
+						i, step.offset, got.Allowed, got.Remaining, step.allowed, step.remaining)
+				}
+				if got.Allowed && got.RetryAfter != 0 {
+					t.Errorf("step %d: RetryAfter = %v for an allowed request", i, got.RetryAfter)
+				}
+				if !got.Allowed && got.RetryAfter <= 0 {
+					t.Errorf("step %d: RetryAfter = %v for a denied request", i, got.RetryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketTimings(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	bucket := TokenBucket{Rate: 2, Burst: 2}
+	var state bucketState
+
+	bucket.take(&state, start)
+	got := bucket.take(&state, start)
+	if got.ResetAfter != time.Second {
+		t.Errorf("ResetAfter of an empty bucket = %v, want 1s", got.ResetAfter)
+	}
+
+	got = bucket.take(&state, start.Add(100*time.Millisecond))
+	if got.Allowed || got.RetryAfter != 400*time.Millisecond {
+		t.Errorf("take after 100ms = %+v, want denied with RetryAfter 400ms", got)
+	}
+}
+
+func TestLocalBucketMatchesTake(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	for _, tt := range bucketSequences {
+		t.Run(tt.name, func(t *testing.T) {
+			var local LocalBucket
+			var state bucketState
+			for i, step := range tt.steps {
+				now := start.Add(step.offset)
+				if got, want := local.Take(tt.bucket, now), tt.bucket.take(&state, now); got != want {
+					t.Fatalf("step %d: LocalBucket.Take = %+v, take = %+v", i, got, want)
+				}
+				if !local.LastUsed().Equal(now) {
+					t.Fatalf("step %d: LastUsed = %v, want %v", i, local.LastUsed(), now)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBucketState(t *testing.T) {
+	updated := time.UnixMicro(1700000000123456)
+	tests := []struct {
+		name    string
+		value   string
+		want    bucketState
+		wantErr bool
+	}{
+		{"round trip", bucketState{tokens: 2.75, updated: updated}.encode(), bucketState{tokens: 2.75, updated: updated}, false},
+		{"empty bucket", bucketState{tokens: 0, updated: updated}.encode(), bucketState{tokens: 0, updated: updated}, false},
+		{"missing separator", "2.75", bucketState{}, true},
+		{"bad tokens", "many 1700000000123456", bucketState{}, true},
+		{"bad timestamp", "2.75 yesterday", bucketState{}, true},
+		{"empty", "", bucketState{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBucketState(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBucketState(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got.tokens != tt.want.tokens || !got.updated.Equal(tt.want.updated) {
+				t.Errorf("decodeBucketState(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRedisTakeTokenMatchesTake runs the Lua script and take through the same burst
+// Needs a Redis server; set REDIS_HOST (and optionally REDIS_PORT) to run it
+func TestRedisTakeTokenMatchesTake(t *testing.T) {
+	client := testRedisClient(t)
+
+	ctx := context.Background()
+	redisBackend, memoryBackend := NewRedisCache(client), NewMemoryCache(100)
+	// A slow refill keeps the few milliseconds between calls from changing the outcome
+	bucket := PerMinute(1, 3)
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	defer client.Del(ctx, RateLimitBucketKeyPrefix+key)
+
+	for i := 0; i < 5; i++ {
+		fromRedis, err := redisBackend.TakeToken(ctx, key, bucket)
+		if err != nil {
+			t.Fatalf("redis TakeToken: %v", err)
+		}
+		fromMemory, err := memoryBackend.TakeToken(ctx, key, bucket)
+		if err != nil {
+			t.Fatalf("memory TakeToken: %v", err)
+		}
+		if fromRedis.Allowed != fromMemory.Allowed || fromRedis.Remaining != fromMemory.Remaining {
+			t.Fatalf("request %d: redis %+v, memory %+v", i, fromRedis, fromMemory)
+		}
+	}
+}
+
+// testRedisClient connects to the Redis server named by REDIS_HOST and REDIS_PORT, skipping the test if unset
+func testRedisClient(t *testing.T) *redis.Client {
+	t.Helper()
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		t.Skip("REDIS_HOST not set")
+	}
+	port := os.Getenv("REDIS_PORT")
+	if port == "" {
+		port = "6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("%s:%s", host, port)})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// WithJitter returns ttl randomly adjusted by up to ±TTLJitterFraction
+// Spreads out the expiry of keys written together so they don't all miss at once
+func WithJitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	spread := int64(float64(ttl) * TTLJitterFraction)
+	if spread <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
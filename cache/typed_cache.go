@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// KeyBuilder builds the cache key for a lookup value of type K
+type KeyBuilder[K any] func(K) string
+
+// PrefixKey returns a KeyBuilder that appends the lookup value to prefix
+// e.g. PrefixKey[int](UserIDKeyPrefix(CodecJSON))(42) == "user:v1:json:id:42"
+func PrefixKey[K any](prefix string) KeyBuilder[K] {
+	return func(k K) string {
+		return fmt.Sprintf("%s%v", prefix, k)
+	}
+}
+
+// TypedCache stores values of type T looked up by K on top of the raw Cache Get/Set operations
+// New entities get caching by composing a key builder and a codec instead of widening the Cache interface
+type TypedCache[K any, T any] struct {
+	cache Cache
+	key   KeyBuilder[K]
+	codec Codec[T]
+}
+
+// NewTypedCache creates a typed view over cacheClient
+func NewTypedCache[K any, T any](cacheClient Cache, key KeyBuilder[K], codec Codec[T]) *TypedCache[K, T] {
+	return &TypedCache[K, T]{
+		cache: cacheClient,
+		key:   key,
+		codec: codec,
+	}
+}
+
+// Key returns the cache key for k
+func (c *TypedCache[K, T]) Key(k K) string {
+	return c.key(k)
+}
+
+// Get retrieves and decodes the value for k
+// Returns ErrCacheMiss if it is not cached
+func (c *TypedCache[K, T]) Get(ctx context.Context, k K) (*T, error) {
+	data, err := c.cache.Get(ctx, c.key(k))
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Decode(data)
+}
+
+// Set encodes and stores value for k with TTL
+func (c *TypedCache[K, T]) Set(ctx context.Context, k K, value *T, ttl time.Duration) error {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, c.key(k), data, ttl)
+}
+
+// Delete removes the values for the given lookups
+func (c *TypedCache[K, T]) Delete(ctx context.Context, keys ...K) error {
+	for _, k := range keys {
+		if err := c.cache.Delete(ctx, c.key(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,21 +1,143 @@
 package config
 
 import (
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/leventeberry/goapi/logger"
 )
 
+// RateLimitTier is a token bucket: bursts of up to BurstSize requests, refilled at RequestsPerMinute
+type RateLimitTier struct {
+	RequestsPerMinute int
+	BurstSize         int
+}
+
 // Config holds all application configuration
 type Config struct {
 	JWT struct {
-		Secret          string
-		ExpirationDays  int
+		Secret         string
+		ExpirationDays int
 	}
 	RateLimit struct {
 		RequestsPerMinute int
 		BurstSize         int
+		// FailOpen allows requests when the shared limiter backend errors; false rejects them
+		FailOpen bool
+		// Headers selects the quota headers sent with responses: "ietf", "legacy", "both" or "none"
+		Headers string
+		// Auth is the strict per-IP limit for login and registration
+		Auth RateLimitTier
+		// User is the per-user limit for authenticated routes, keyed on the JWT subject
+		User RateLimitTier
+		// Admin replaces User for tokens with the admin role
+		Admin RateLimitTier
+	}
+	Cache struct {
+		// Backend selects the cache implementation: "redis", "tiered", "memory" or "none"
+		Backend string
+		// MemoryMaxEntries bounds the in-memory cache (memory backend, and the L1 of the tiered backend)
+		MemoryMaxEntries int
+		// L1TTL caps how long the tiered backend keeps entries in the local L1 cache
+		L1TTL time.Duration
+		// Codec selects how cached values are serialized: "json", "msgpack" or "gob"
+		Codec string
+	}
+	Proxy struct {
+		// TrustedProxies are the IPs/CIDRs whose forwarding headers are believed (none by default)
+		TrustedProxies []string
+		// ClientIPHeaders are checked for the client IP when the peer is a trusted proxy
+		ClientIPHeaders []string
+		// TrustedPlatform is a platform name ("cloudflare", "google-app-engine", "fly") or a header set by the platform
+		TrustedPlatform string
+		// IPv6PrefixLength groups IPv6 clients into one rate limit key per prefix (/64 is one subscriber)
+		IPv6PrefixLength int
+	}
+	IPFilter struct {
+		// Allow lists IPs/CIDRs that bypass deny rules, bans and rate limits (e.g. internal monitoring)
+		Allow []string
+		// Deny lists IPs/CIDRs that are always rejected
+		Deny []string
+		// BanThreshold is how many rate limit rejections within BanWindow trigger a ban (0 disables bans)
+		BanThreshold int
+		BanWindow    time.Duration
+		// BanDuration is the first ban's length; each repeat ban doubles it, up to BanMaxDuration
+		BanDuration    time.Duration
+		BanMaxDuration time.Duration
+		// RulesRefresh is how often each instance reloads admin-managed rules from the cache
+		RulesRefresh time.Duration
+	}
+	Metrics struct {
+		// Enabled exposes Prometheus metrics at /metrics
+		Enabled bool
+		// Addr serves /metrics on a separate listener (e.g. ":9090") instead of the API port
+		Addr string
+		// Username and Password protect /metrics with basic auth when both are set
+		Username string
+		Password string
+	}
+	Debug struct {
+		// Addr enables the admin/debug listener (pprof, expvar, build info, config, log level), e.g. "localhost:6060"
+		Addr string
+		// Username and Password protect the debug listener with basic auth; required unless Addr is a loopback address
+		Username string
+		Password string
+	}
+	Health struct {
+		// CacheTTL is how long a check result is reused before the dependency is checked again
+		CacheTTL time.Duration
+		// CheckTimeout bounds each individual check
+		CheckTimeout time.Duration
+		// DiskPath is checked for at least DiskMinFreeMB of free space
+		DiskPath      string
+		DiskMinFreeMB int
+		// ShutdownDrainDelay keeps serving (with /readyz failing) for this long before shutting down
+		ShutdownDrainDelay time.Duration
+	}
+	Tracing struct {
+		// Exporter selects where spans are sent: "otlp", "stdout" or "none"
+		Exporter string
+		// ServiceName is reported as service.name on every span
+		ServiceName string
+		// SampleRatio is the share of new traces that are recorded (0-1); sampled parents are always followed
+		SampleRatio float64
+	}
+	Database struct {
+		// URL is a full connection string (DATABASE_URL) that overrides the individual fields below
+		URL      string
+		Host     string
+		Port     string
+		User     string
+		Password string
+		Name     string
+
+		// ReplicaURLs are connection strings for read replicas (DB_REPLICA_URLS, comma-separated)
+		ReplicaURLs []string
+
+		// TLS settings, see https://www.postgresql.org/docs/current/libpq-ssl.html
+		SSLMode     string
+		SSLRootCert string
+		SSLCert     string
+		SSLKey      string
+
+		// Connection pool settings applied to the underlying sql.DB
+		MaxOpenConns    int
+		MaxIdleConns    int
+		ConnMaxLifetime time.Duration
+		ConnMaxIdleTime time.Duration
+
+		// StatementTimeout aborts any statement that runs longer than this (0 disables it)
+		StatementTimeout time.Duration
+
+		// QueryTimeout is the deadline for each repository call made while serving a request (0 disables it)
+		QueryTimeout time.Duration
+
+		// Startup connection retries
+		ConnectRetries      int
+		ConnectRetryBackoff time.Duration
 	}
 }
 
@@ -71,10 +193,247 @@ func Load() *Config {
 		}
 	}
 
+	// RATE_LIMIT_FAILURE_POLICY decides what happens when the cache backing the limiter fails:
+	// "open" (default) lets requests through, "closed" rejects them
+	switch policy := getEnv("RATE_LIMIT_FAILURE_POLICY", "open"); policy {
+	case "open":
+		cfg.RateLimit.FailOpen = true
+	case "closed":
+		cfg.RateLimit.FailOpen = false
+	default:
+		logger.Log.Warn().Str("value", policy).Str("default", "open").Msg("Invalid RATE_LIMIT_FAILURE_POLICY, using default")
+		cfg.RateLimit.FailOpen = true
+	}
+
+	// RATE_LIMIT_HEADERS: "ietf" (RateLimit-*), "legacy" (X-RateLimit-*), "both" or "none"
+	cfg.RateLimit.Headers = getEnv("RATE_LIMIT_HEADERS", "ietf")
+	switch cfg.RateLimit.Headers {
+	case "ietf", "legacy", "both", "none":
+	default:
+		logger.Log.Warn().Str("value", cfg.RateLimit.Headers).Str("default", "ietf").Msg("Invalid RATE_LIMIT_HEADERS, using default")
+		cfg.RateLimit.Headers = "ietf"
+	}
+
+	// Per-route policies (see middleware.DefaultRateLimitPolicies)
+	cfg.RateLimit.Auth = getEnvTier("RATE_LIMIT_AUTH", RateLimitTier{RequestsPerMinute: 10, BurstSize: 10})
+	cfg.RateLimit.User = getEnvTier("RATE_LIMIT_USER", RateLimitTier{RequestsPerMinute: 300, BurstSize: 50})
+	cfg.RateLimit.Admin = getEnvTier("RATE_LIMIT_ADMIN", RateLimitTier{RequestsPerMinute: 1200, BurstSize: 200})
+
+	// Proxy Configuration
+	// Forwarding headers are ignored unless the direct peer is in TRUSTED_PROXIES
+	cfg.Proxy.TrustedProxies = getEnvList("TRUSTED_PROXIES", nil)
+	cfg.Proxy.ClientIPHeaders = getEnvList("CLIENT_IP_HEADERS", []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"})
+	cfg.Proxy.TrustedPlatform = os.Getenv("TRUSTED_PLATFORM")
+	cfg.Proxy.IPv6PrefixLength = getEnvInt("RATE_LIMIT_IPV6_PREFIX", 64, 1)
+	if cfg.Proxy.IPv6PrefixLength > 128 {
+		logger.Log.Warn().Int("value", cfg.Proxy.IPv6PrefixLength).Int("default", 64).Msg("Invalid RATE_LIMIT_IPV6_PREFIX, using default")
+		cfg.Proxy.IPv6PrefixLength = 64
+	}
+
+	// IP Filter Configuration
+	cfg.IPFilter.Allow = getEnvCIDRs("IP_ALLOWLIST")
+	cfg.IPFilter.Deny = getEnvCIDRs("IP_DENYLIST")
+	cfg.IPFilter.BanThreshold = getEnvInt("IP_BAN_THRESHOLD", 10, 0)
+	cfg.IPFilter.BanWindow = getEnvDuration("IP_BAN_WINDOW", 10*time.Minute)
+	cfg.IPFilter.BanDuration = getEnvDuration("IP_BAN_DURATION", 5*time.Minute)
+	cfg.IPFilter.BanMaxDuration = getEnvDuration("IP_BAN_MAX_DURATION", 24*time.Hour)
+	if cfg.IPFilter.BanMaxDuration < cfg.IPFilter.BanDuration {
+		cfg.IPFilter.BanMaxDuration = cfg.IPFilter.BanDuration
+	}
+	cfg.IPFilter.RulesRefresh = getEnvDuration("IP_RULES_REFRESH", 10*time.Second)
+
+	// Cache Configuration
+	// CACHE_BACKEND defaults to "redis" when REDIS_ENABLED=true, otherwise "none"
+	defaultBackend := "none"
+	if os.Getenv("REDIS_ENABLED") == "true" {
+		defaultBackend = "redis"
+	}
+	cfg.Cache.Backend = getEnv("CACHE_BACKEND", defaultBackend)
+	switch cfg.Cache.Backend {
+	case "redis", "tiered", "memory", "none":
+	default:
+		logger.Log.Warn().Str("value", cfg.Cache.Backend).Str("default", defaultBackend).Msg("Invalid CACHE_BACKEND, using default")
+		cfg.Cache.Backend = defaultBackend
+	}
+	cfg.Cache.MemoryMaxEntries = getEnvInt("CACHE_MEMORY_MAX_ENTRIES", 10000, 1)
+	cfg.Cache.L1TTL = getEnvDuration("CACHE_L1_TTL", 30*time.Second)
+	cfg.Cache.Codec = getEnv("CACHE_CODEC", "json")
+	switch cfg.Cache.Codec {
+	case "json", "msgpack", "gob":
+	default:
+		logger.Log.Warn().Str("value", cfg.Cache.Codec).Str("default", "json").Msg("Invalid CACHE_CODEC, using default")
+		cfg.Cache.Codec = "json"
+	}
+
+	// Metrics Configuration
+	cfg.Metrics.Enabled = os.Getenv("METRICS_ENABLED") != "false"
+	cfg.Metrics.Addr = os.Getenv("METRICS_ADDR")
+	cfg.Metrics.Username = os.Getenv("METRICS_USERNAME")
+	cfg.Metrics.Password = os.Getenv("METRICS_PASSWORD")
+	// Only one of the two would silently serve metrics without authentication
+	if (cfg.Metrics.Username == "") != (cfg.Metrics.Password == "") {
+		logger.Log.Fatal().Msg("METRICS_USERNAME and METRICS_PASSWORD must be set together")
+	}
+
+	// Debug Server Configuration
+	cfg.Debug.Addr = os.Getenv("DEBUG_ADDR")
+	cfg.Debug.Username = os.Getenv("DEBUG_USERNAME")
+	cfg.Debug.Password = os.Getenv("DEBUG_PASSWORD")
+
+	// Health Check Configuration
+	cfg.Health.CacheTTL = getEnvDuration("HEALTH_CACHE_TTL", 2*time.Second)
+	cfg.Health.CheckTimeout = getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	cfg.Health.DiskPath = getEnv("HEALTH_DISK_PATH", ".")
+	cfg.Health.DiskMinFreeMB = getEnvInt("HEALTH_DISK_MIN_FREE_MB", 100, 0)
+	cfg.Health.ShutdownDrainDelay = getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second)
+
+	// Tracing Configuration
+	cfg.Tracing.Exporter = getEnv("TRACING_EXPORTER", "none")
+	switch cfg.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		logger.Log.Warn().Str("value", cfg.Tracing.Exporter).Str("default", "none").Msg("Invalid TRACING_EXPORTER, using default")
+		cfg.Tracing.Exporter = "none"
+	}
+	cfg.Tracing.ServiceName = getEnv("OTEL_SERVICE_NAME", "goapi")
+	cfg.Tracing.SampleRatio = getEnvRatio("TRACING_SAMPLE_RATIO", 1)
+
+	// Database Configuration
+	cfg.Database.URL = os.Getenv("DATABASE_URL")
+	cfg.Database.Host = os.Getenv("DB_HOST")
+	cfg.Database.Port = getEnv("DB_PORT", "5432")
+	cfg.Database.User = os.Getenv("DB_USER")
+	cfg.Database.Password = os.Getenv("DB_PASS")
+	cfg.Database.Name = os.Getenv("DB_NAME")
+	cfg.Database.ReplicaURLs = getEnvList("DB_REPLICA_URLS", nil)
+
+	cfg.Database.SSLMode = getEnv("DB_SSLMODE", "disable")
+	switch cfg.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		logger.Log.Warn().Str("value", cfg.Database.SSLMode).Msg("Invalid DB_SSLMODE, using default disable")
+		cfg.Database.SSLMode = "disable"
+	}
+	cfg.Database.SSLRootCert = os.Getenv("DB_SSLROOTCERT")
+	cfg.Database.SSLCert = os.Getenv("DB_SSLCERT")
+	cfg.Database.SSLKey = os.Getenv("DB_SSLKEY")
+
+	cfg.Database.MaxOpenConns = getEnvInt("DB_MAX_OPEN_CONNS", 25, 1)
+	cfg.Database.MaxIdleConns = getEnvInt("DB_MAX_IDLE_CONNS", 10, 0)
+	if cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+		logger.Log.Warn().
+			Int("max_idle_conns", cfg.Database.MaxIdleConns).
+			Int("max_open_conns", cfg.Database.MaxOpenConns).
+			Msg("DB_MAX_IDLE_CONNS exceeds DB_MAX_OPEN_CONNS, capping idle connections")
+		cfg.Database.MaxIdleConns = cfg.Database.MaxOpenConns
+	}
+	cfg.Database.ConnMaxLifetime = getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	cfg.Database.ConnMaxIdleTime = getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	cfg.Database.StatementTimeout = getEnvDuration("DB_STATEMENT_TIMEOUT", 0)
+	cfg.Database.QueryTimeout = getEnvDuration("DB_QUERY_TIMEOUT", 10*time.Second)
+
+	cfg.Database.ConnectRetries = getEnvInt("DB_CONNECT_RETRIES", 5, 0)
+	cfg.Database.ConnectRetryBackoff = getEnvDuration("DB_CONNECT_RETRY_BACKOFF", time.Second)
+
 	AppConfig = cfg
 	return cfg
 }
 
+// getEnv returns the value of an environment variable or the default if unset
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt parses an integer environment variable
+// Falls back to the default (with a warning) if the value is invalid or below minValue
+func getEnvInt(key string, defaultValue, minValue int) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < minValue {
+		logger.Log.Warn().Str("value", valueStr).Int("default", defaultValue).Msgf("Invalid %s, using default", key)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvRatio parses a float environment variable between 0 and 1
+// Falls back to the default (with a warning) if the value is invalid or out of range
+func getEnvRatio(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil || value < 0 || value > 1 {
+		logger.Log.Warn().Str("value", valueStr).Float64("default", defaultValue).Msgf("Invalid %s, using default", key)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList parses a comma-separated environment variable, skipping empty items
+func getEnvList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, value := range strings.Split(valueStr, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvCIDRs parses a comma-separated list of IPs/CIDRs, skipping (with a warning) invalid entries
+func getEnvCIDRs(key string) []string {
+	var cidrs []string
+	for _, value := range getEnvList(key, nil) {
+		var err error
+		if strings.Contains(value, "/") {
+			_, err = netip.ParsePrefix(value)
+		} else {
+			_, err = netip.ParseAddr(value)
+		}
+		if err != nil {
+			logger.Log.Warn().Str("value", value).Msgf("Invalid entry in %s, ignoring", key)
+			continue
+		}
+		cidrs = append(cidrs, value)
+	}
+	return cidrs
+}
+
+// getEnvTier reads {prefix}_REQUESTS_PER_MINUTE and {prefix}_BURST_SIZE
+func getEnvTier(prefix string, defaultValue RateLimitTier) RateLimitTier {
+	return RateLimitTier{
+		RequestsPerMinute: getEnvInt(prefix+"_REQUESTS_PER_MINUTE", defaultValue.RequestsPerMinute, 1),
+		BurstSize:         getEnvInt(prefix+"_BURST_SIZE", defaultValue.BurstSize, 1),
+	}
+}
+
+// getEnvDuration parses a duration environment variable (e.g. "30s", "5m")
+// Falls back to the default (with a warning) if the value is invalid or negative
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value < 0 {
+		logger.Log.Warn().Str("value", valueStr).Dur("default", defaultValue).Msgf("Invalid %s, using default", key)
+		return defaultValue
+	}
+	return value
+}
+
 // Get returns the global configuration instance
 func Get() *Config {
 	if AppConfig == nil {
@@ -82,4 +441,3 @@ func Get() *Config {
 	}
 	return AppConfig
 }
-
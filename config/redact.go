@@ -0,0 +1,86 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// redactedValue replaces secrets in the configuration dump
+const redactedValue = "[REDACTED]"
+
+// redactedPassword replaces passwords inside connection strings, matching url.Redacted
+const redactedPassword = "xxxxx"
+
+// secretFieldMarkers flag string fields holding secrets, matched against the field name
+var secretFieldMarkers = []string{"Secret", "Password", "Token", "Key"}
+
+// dsnPasswordPattern matches the password of a keyword/value DSN, e.g. "host=db user=app password=secret"
+// Quoted values may contain spaces and backslash escapes: password='it\'s secret'
+var dsnPasswordPattern = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'?|[^\s]*)`)
+
+// Redacted returns a copy of the configuration that is safe to expose on the debug server
+// Secret fields are masked, and passwords are removed from connection URLs and DSNs
+// Matching is by field name so new secrets are covered without touching this file
+func (c *Config) Redacted() Config {
+	redacted := *c
+	redactValue(reflect.ValueOf(&redacted).Elem(), "")
+	return redacted
+}
+
+// redactValue walks v, masking secret string fields and cleaning URLs in place
+func redactValue(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				redactValue(v.Field(i), v.Type().Field(i).Name)
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		// Copy so the live configuration's backing array is never modified
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = redactString(v.Index(i).String(), name)
+		}
+		v.Set(reflect.ValueOf(values))
+	case reflect.String:
+		v.SetString(redactString(v.String(), name))
+	}
+}
+
+// redactString masks value if the field is a secret, or strips the password if it is a connection string
+func redactString(value, name string) string {
+	if value == "" {
+		return value
+	}
+	for _, marker := range secretFieldMarkers {
+		if strings.Contains(name, marker) {
+			return redactedValue
+		}
+	}
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			// An unparseable URL may still hold a password, so none of it is shown
+			return redactedValue
+		}
+		// url.Redacted masks the userinfo password as "xxxxx"; password query parameters get the same treatment
+		query := u.Query()
+		for key := range query {
+			if strings.Contains(strings.ToLower(key), "password") {
+				query.Set(key, redactedPassword)
+			}
+		}
+		if len(query) > 0 {
+			u.RawQuery = query.Encode()
+		}
+		return u.Redacted()
+	}
+	// Keyword/value DSNs as accepted by pgx and libpq
+	return dsnPasswordPattern.ReplaceAllString(value, "${1}"+redactedPassword)
+}
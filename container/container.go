@@ -1,8 +1,14 @@
 package container
 
 import (
+	"fmt"
+
 	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
 	"github.com/leventeberry/goapi/factories"
+	"github.com/leventeberry/goapi/health"
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/models"
 	"github.com/leventeberry/goapi/repositories"
 	"github.com/leventeberry/goapi/services"
 	"gorm.io/gorm"
@@ -16,25 +22,49 @@ type Container struct {
 	RepositoryFactory *factories.RepositoryFactory
 	ServiceFactory    *factories.ServiceFactory
 	UserRepository    repositories.UserRepository
+	AuditRepository   repositories.AuditRepository
+	TxManager         repositories.TransactionManager
 	UserService       services.UserService
 	AuthService       services.AuthService
+	AuditService      services.AuditService
+	CacheService      services.CacheService
+	IPFilterService   services.IPFilterService
+	RateLimiters      *middleware.RateLimiters
+	IPFilter          *middleware.IPFilter
+	Health            *health.Registry
 }
 
 // NewContainer creates and initializes a new dependency injection container
 // Uses Factory Pattern to create all dependencies
-func NewContainer(db *gorm.DB, cacheClient cache.Cache) *Container {
+// Optional replicas are used for read-only repository queries
+func NewContainer(db *gorm.DB, cacheClient cache.Cache, replicas ...*gorm.DB) *Container {
 	// Create repository factory
-	repoFactory := factories.NewRepositoryFactory(db)
+	repoFactory := factories.NewRepositoryFactory(db, replicas...)
 
 	// Create repositories
 	userRepo := repoFactory.CreateUserRepository()
+	auditRepo := repoFactory.CreateAuditRepository()
+	txManager := repoFactory.CreateTransactionManager()
 
 	// Create service factory with cache client
-	serviceFactory := factories.NewServiceFactory(userRepo, cacheClient)
+	serviceFactory := factories.NewServiceFactory(userRepo, auditRepo, txManager, cacheClient)
 
 	// Create services
 	userService := serviceFactory.CreateUserService()
 	authService := serviceFactory.CreateAuthService()
+	auditService := serviceFactory.CreateAuditService()
+	cacheService := serviceFactory.CreateCacheService()
+
+	// Rate limit policies share the cache so limits hold across instances
+	rateLimiters := middleware.NewRateLimiters(cacheClient)
+
+	// IP filter bans clients that keep hitting rate limits
+	ipFilter := middleware.NewIPFilter(cacheClient, config.Get())
+	rateLimiters.OnReject(ipFilter.RecordViolation)
+	ipFilterService := serviceFactory.CreateIPFilterService(ipFilter)
+
+	// Health checks back the /livez, /readyz and /startupz probes
+	healthRegistry := newHealthRegistry(db, cacheClient, replicas)
 
 	return &Container{
 		DB:                db,
@@ -42,8 +72,36 @@ func NewContainer(db *gorm.DB, cacheClient cache.Cache) *Container {
 		RepositoryFactory: repoFactory,
 		ServiceFactory:    serviceFactory,
 		UserRepository:    userRepo,
+		AuditRepository:   auditRepo,
+		TxManager:         txManager,
 		UserService:       userService,
 		AuthService:       authService,
+		AuditService:      auditService,
+		CacheService:      cacheService,
+		IPFilterService:   ipFilterService,
+		RateLimiters:      rateLimiters,
+		IPFilter:          ipFilter,
+		Health:            healthRegistry,
 	}
 }
 
+// newHealthRegistry registers the dependency checks for each probe
+// The primary database gates readiness; the cache and replicas are optional because the API degrades without them
+func newHealthRegistry(db *gorm.DB, cacheClient cache.Cache, replicas []*gorm.DB) *health.Registry {
+	cfg := config.Get().Health
+	registry := health.NewRegistry(cfg.CacheTTL, cfg.CheckTimeout)
+
+	registry.Register(health.DBChecker("database", db), health.Readiness, health.Startup)
+	registry.Register(health.MigrationsChecker(db, models.Migrated()...), health.Startup)
+	for i, replica := range replicas {
+		registry.RegisterOptional(health.DBChecker(fmt.Sprintf("replica-%d", i+1), replica), health.Readiness)
+	}
+	if cacheClient != nil {
+		registry.RegisterOptional(health.CacheChecker(cacheClient), health.Readiness)
+	}
+	if cfg.DiskMinFreeMB > 0 {
+		registry.Register(health.DiskChecker(cfg.DiskPath, uint64(cfg.DiskMinFreeMB)<<20), health.Readiness)
+	}
+
+	return registry
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/services"
+	"github.com/rs/zerolog"
+)
+
+// auditFormatJSONL selects the JSON Lines export instead of the paginated response
+const auditFormatJSONL = "jsonl"
+
+// AuditQuery holds the filters and paging parameters of an audit log query
+type AuditQuery struct {
+	Action     string    `form:"action" binding:"omitempty,max=64"`
+	ActorID    *int      `form:"actor_id" binding:"omitempty,min=1"`
+	TargetType string    `form:"target_type" binding:"omitempty,max=20"`
+	TargetID   string    `form:"target_id" binding:"omitempty,max=100"`
+	IP         string    `form:"ip" binding:"omitempty,ip"`
+	RequestID  string    `form:"request_id" binding:"omitempty,max=128"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int       `form:"page" binding:"omitempty,min=1"`
+	PageSize   int       `form:"page_size" binding:"omitempty,min=1"`
+	Format     string    `form:"format" binding:"omitempty,oneof=json jsonl"`
+}
+
+// filter converts the query to the service filter
+func (q *AuditQuery) filter() *services.AuditFilter {
+	return &services.AuditFilter{
+		Action:     q.Action,
+		ActorID:    q.ActorID,
+		TargetType: q.TargetType,
+		TargetID:   q.TargetID,
+		IP:         q.IP,
+		RequestID:  q.RequestID,
+		From:       q.From,
+		To:         q.To,
+	}
+}
+
+// GetAuditEvents lists or exports audit log events
+// @Summary      Query audit log
+// @Description  List audit events, newest first, or export every matching event as JSON Lines, oldest first, with format=jsonl (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Security     BearerAuth
+// @Param        action       query     string  false  "Action, e.g. user.delete, user.role_change, auth.login_failed"
+// @Param        actor_id     query     int     false  "ID of the user who acted"
+// @Param        target_type  query     string  false  "Target type: user or email"
+// @Param        target_id    query     string  false  "Target ID (user ID, or email for failed logins)"
+// @Param        ip           query     string  false  "Client IP"
+// @Param        request_id   query     string  false  "Request ID"
+// @Param        from         query     string  false  "Earliest event time, inclusive (RFC 3339)"
+// @Param        to           query     string  false  "Latest event time, exclusive (RFC 3339)"
+// @Param        page         query     int     false  "Page number (default: 1)"
+// @Param        page_size    query     int     false  "Items per page (default: 10, max: 100)"
+// @Param        format       query     string  false  "json (default, paginated) or jsonl (export)"
+// @Success      200          {object}  map[string]interface{}  "Paginated audit events"
+// @Failure      400          {object}  middleware.Problem  "Invalid filter"
+// @Failure      401          {object}  middleware.Problem  "Unauthorized"
+// @Failure      403          {object}  middleware.Problem  "Forbidden"
+// @Failure      500          {object}  middleware.Problem  "Server error"
+// @Router       /admin/audit [get]
+func GetAuditEvents(auditService services.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query AuditQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		if query.Format == auditFormatJSONL {
+			exportAuditEvents(c, auditService, query.filter())
+			return
+		}
+
+		params := &services.PaginationParams{Page: query.Page, PageSize: query.PageSize}
+		events, total, err := auditService.ListEvents(c.Request.Context(), query.filter(), params)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		// Apply capping logic here to match service behavior
+		page := max(query.Page, 1)
+		pageSize := query.PageSize
+		if pageSize < 1 {
+			pageSize = 10
+		}
+		if pageSize > 100 {
+			pageSize = 100
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":        events,
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+			"total_pages": (int(total) + pageSize - 1) / pageSize, // Ceiling division
+		})
+	}
+}
+
+// exportAuditEvents streams every matching event as one JSON object per line
+// Headers are sent with the first event, so an error before it still gets a normal error response
+func exportAuditEvents(c *gin.Context, auditService services.AuditService, filter *services.AuditFilter) {
+	started := false
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().UTC().Format("20060102T150405Z")))
+		c.Status(http.StatusOK)
+	}
+
+	encoder := json.NewEncoder(c.Writer)
+	err := auditService.ExportEvents(c.Request.Context(), filter, func(event *models.AuditEvent) error {
+		start()
+		return encoder.Encode(event)
+	})
+	if err != nil {
+		if !started {
+			c.Error(err)
+			return
+		}
+		// Too late to change the status; the truncated export shows up in the logs
+		zerolog.Ctx(c.Request.Context()).Error().Err(err).Msg("Audit log export aborted")
+		return
+	}
+
+	// An export with no matching events is an empty file
+	start()
+	c.Writer.WriteHeaderNow()
+}
@@ -11,7 +11,7 @@ import (
 
 // RequestUserInput holds login credentials
 type RequestUserInput struct {
-	Email    string `json:"email" binding:"required,email"`
+	Email    string `json:"email" binding:"required,email,max=255"`
 	Password string `json:"password" binding:"required"`
 }
 
@@ -44,21 +44,21 @@ func ReturnSuccessData(c *gin.Context, user *models.User, token *middleware.Auth
 // @Produce      json
 // @Param        credentials  body      RequestUserInput  true  "Login credentials"
 // @Success      200          {object}  map[string]interface{}  "Login successful"
-// @Failure      400          {object}  map[string]string  "Invalid request"
-// @Failure      401          {object}  map[string]string  "Invalid credentials"
-// @Failure      500          {object}  map[string]string  "Server error"
+// @Failure      400          {object}  middleware.Problem  "Invalid request"
+// @Failure      401          {object}  middleware.Problem  "Invalid credentials"
+// @Failure      500          {object}  middleware.Problem  "Server error"
 // @Router       /login [post]
 func LoginUser(authService services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input RequestUserInput
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err).SetType(gin.ErrorTypeBind)
 			return
 		}
 
-		user, token, err := authService.Login(input.Email, input.Password)
+		user, token, err := authService.Login(c.Request.Context(), input.Email, input.Password)
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
@@ -74,21 +74,21 @@ func LoginUser(authService services.AuthService) gin.HandlerFunc {
 // @Produce      json
 // @Param        user  body      SignupUserInput  true  "User registration data"
 // @Success      200   {object}  map[string]interface{}  "Registration successful"
-// @Failure      400   {object}  map[string]string  "Invalid request"
-// @Failure      409   {object}  map[string]string  "Email already registered"
-// @Failure      500   {object}  map[string]string  "Server error"
+// @Failure      400   {object}  middleware.Problem  "Invalid request"
+// @Failure      409   {object}  middleware.Problem  "Email already registered"
+// @Failure      500   {object}  middleware.Problem  "Server error"
 // @Router       /register [post]
 func SignupUser(authService services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input SignupUserInput
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err).SetType(gin.ErrorTypeBind)
 			return
 		}
 
 		// Validate password strength
 		if err := services.ValidatePasswordStrength(input.Password); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
@@ -101,9 +101,9 @@ func SignupUser(authService services.AuthService) gin.HandlerFunc {
 			Role:      input.Role,
 		}
 
-		user, token, err := authService.Register(registerInput)
+		user, token, err := authService.Register(c.Request.Context(), registerInput)
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
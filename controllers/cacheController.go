@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/services"
+)
+
+// GetCacheStats returns cache hit/miss/error/latency counters per key family
+// @Summary      Cache statistics
+// @Description  Get cumulative cache counters grouped by key family (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}  "Cache statistics"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Forbidden"
+// @Router       /admin/cache/stats [get]
+func GetCacheStats(cacheService services.CacheService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"families": cacheService.GetStats()})
+	}
+}
+
+// InspectCacheKey returns the remaining TTL of a cache key
+// @Summary      Inspect cache key
+// @Description  Get the remaining time to live of a cache key (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key  query     string  true  "Full cache key, e.g. user:v1:json:id:42 (user keys include the CACHE_CODEC name, json by default)"
+// @Success      200  {object}  map[string]interface{}  "Key details"
+// @Failure      400  {object}  middleware.Problem  "Missing key"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Forbidden"
+// @Failure      404  {object}  middleware.Problem  "Key not found"
+// @Failure      500  {object}  middleware.Problem  "Server error"
+// @Router       /admin/cache/keys [get]
+func InspectCacheKey(cacheService services.CacheService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info, err := cacheService.InspectKey(c.Request.Context(), c.Query("key"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		response := gin.H{
+			"key":     info.Key,
+			"family":  info.Family,
+			"expires": info.Expires,
+		}
+		if info.Expires {
+			response["ttl_seconds"] = info.TTL.Seconds()
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// EvictCachePrefix deletes every cache key starting with a prefix
+// @Summary      Evict cache keys by prefix
+// @Description  Delete all cache keys starting with the given prefix, e.g. "user:" (admin only). Rate limit keys are never deleted
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        prefix  query     string  true  "Key prefix, e.g. user:"
+// @Success      200     {object}  map[string]interface{}  "Number of deleted keys"
+// @Failure      400     {object}  middleware.Problem  "Missing or protected prefix"
+// @Failure      401     {object}  middleware.Problem  "Unauthorized"
+// @Failure      403     {object}  middleware.Problem  "Forbidden"
+// @Failure      500     {object}  middleware.Problem  "Server error"
+// @Router       /admin/cache/keys [delete]
+func EvictCachePrefix(cacheService services.CacheService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prefix := c.Query("prefix")
+		deleted, err := cacheService.EvictPrefix(c.Request.Context(), prefix)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"prefix": prefix, "deleted": deleted})
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/buildinfo"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/logger"
+	"github.com/leventeberry/goapi/middleware"
+)
+
+// LogLevelInput holds the requested log level
+type LogLevelInput struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// GetBuildInfo returns the version, commit and Go version of the running binary
+// Served on the debug listener only
+func GetBuildInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get())
+	}
+}
+
+// GetConfig returns the effective configuration with secrets redacted
+// Served on the debug listener only
+func GetConfig() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, config.Get().Redacted())
+	}
+}
+
+// GetLogLevel returns the current log level
+// Served on the debug listener only
+func GetLogLevel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": logger.Level().String()})
+	}
+}
+
+// SetLogLevel changes the log level until the next restart
+// Served on the debug listener only
+func SetLogLevel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input LogLevelInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		previous := logger.Level()
+		if err := logger.SetLevel(input.Level); err != nil {
+			c.Error(middleware.ValidationProblem(middleware.FieldError{
+				Field:   "level",
+				Code:    "invalid_log_level",
+				Message: "must be one of: trace, debug, info, warn, error, fatal, panic, disabled",
+			}))
+			return
+		}
+		logger.Log.Warn().Str("previous", previous.String()).Str("current", logger.Level().String()).Msg("Log level changed at runtime")
+		c.JSON(http.StatusOK, gin.H{"level": logger.Level().String()})
+	}
+}
@@ -1,27 +1,68 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/middleware"
 	"github.com/leventeberry/goapi/services"
 )
 
-// handleServiceError converts service errors to appropriate HTTP responses
-func handleServiceError(c *gin.Context, err error) {
-	switch err {
-	case services.ErrInvalidCredentials:
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
-	case services.ErrEmailExists:
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
-	case services.ErrInvalidRole:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Valid roles are: user, admin"})
-	case services.ErrUserNotFound:
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-	case services.ErrNoFieldsToUpdate:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one field must be provided for update"})
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-	}
+// serviceProblem maps a service error to its HTTP status and stable code
+type serviceProblem struct {
+	err    error
+	status int
+	code   string
+	detail string
+}
+
+// serviceProblems lists every service error a client can trigger
+// Codes are part of the API contract: add new ones, never rename existing ones
+var serviceProblems = []serviceProblem{
+	{services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "Invalid email or password"},
+	{services.ErrEmailExists, http.StatusConflict, "email_exists", "Email already registered"},
+	{services.ErrInvalidRole, http.StatusBadRequest, "invalid_role", "Invalid role. Valid roles are: user, admin"},
+	{services.ErrUserNotFound, http.StatusNotFound, "user_not_found", "User not found"},
+	{services.ErrNoFieldsToUpdate, http.StatusBadRequest, "no_fields_to_update", "At least one field must be provided for update"},
+	{services.ErrConflict, http.StatusConflict, "conflict", "Resource conflicts with an existing resource"},
+	{services.ErrConstraintViolation, http.StatusBadRequest, "constraint_violation", "Request data violates a database constraint"},
+	{services.ErrInvalidCacheKey, http.StatusBadRequest, "invalid_cache_key", "Query parameter 'key' is required"},
+	{services.ErrCacheKeyNotFound, http.StatusNotFound, "cache_key_not_found", "Cache key not found"},
+	{services.ErrProtectedCachePrefix, http.StatusBadRequest, "protected_cache_prefix", "Prefix must be non-empty and must not match rate limit or IP filter keys"},
+	{services.ErrInvalidIPRule, http.StatusBadRequest, "invalid_ip_rule", "Invalid rule: cidr must be an IP or CIDR and action must be allow or deny"},
+	{services.ErrIPRuleExists, http.StatusConflict, "ip_rule_exists", "IP rule already exists"},
+	{services.ErrIPRuleNotFound, http.StatusNotFound, "ip_rule_not_found", "IP rule not found"},
+	{services.ErrInvalidIPAddress, http.StatusBadRequest, "invalid_ip_address", "Invalid IP address"},
+	{services.ErrIPBanNotFound, http.StatusNotFound, "ip_ban_not_found", "IP is not banned"},
+	{services.ErrIPFilterUnavailable, http.StatusServiceUnavailable, "ip_filter_unavailable", "IP rules and bans are unavailable because the cache backend does not store values"},
+	{services.ErrInvalidAuditFilter, http.StatusBadRequest, "invalid_audit_filter", "Invalid audit filter: 'from' must be before 'to'"},
+}
+
+// passwordProblems maps password strength errors to codes of a validation error on the password field
+var passwordProblems = []struct {
+	err  error
+	code string
+}{
+	{services.ErrPasswordTooShort, "password_too_short"},
+	{services.ErrPasswordNoUpper, "password_no_upper"},
+	{services.ErrPasswordNoLower, "password_no_lower"},
+	{services.ErrPasswordNoNumber, "password_no_number"},
+	{services.ErrPasswordNoSpecial, "password_no_special"},
 }
 
+// ProblemFor converts a service error to a problem for middleware.ErrorHandler
+// Errors are matched with errors.Is, so wrapped service errors are recognized too
+// Returns nil for unexpected errors, which the middleware reports as a 500
+func ProblemFor(err error) *middleware.Problem {
+	for _, p := range serviceProblems {
+		if errors.Is(err, p.err) {
+			return middleware.NewProblem(p.status, p.code, p.detail)
+		}
+	}
+	for _, p := range passwordProblems {
+		if errors.Is(err, p.err) {
+			return middleware.ValidationProblem(middleware.FieldError{Field: "password", Code: p.code, Message: p.err.Error()})
+		}
+	}
+	return nil
+}
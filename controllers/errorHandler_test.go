@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/services"
+)
+
+// problemBody is the decoded problem response
+type problemBody struct {
+	Status int                     `json:"status"`
+	Code   string                  `json:"code"`
+	Detail string                  `json:"detail"`
+	Errors []middleware.FieldError `json:"errors"`
+}
+
+// serveError renders err through the error handler with the service mapping
+func serveError(t *testing.T, err error) (*httptest.ResponseRecorder, problemBody) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(ProblemFor))
+	router.GET("/", func(c *gin.Context) {
+		c.Error(err)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body problemBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	return w, body
+}
+
+func TestErrorHandlerMapsServiceErrors(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+		{services.ErrEmailExists, http.StatusConflict, "email_exists"},
+		{services.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+		{services.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+		{services.ErrNoFieldsToUpdate, http.StatusBadRequest, "no_fields_to_update"},
+		{services.ErrConflict, http.StatusConflict, "conflict"},
+		{services.ErrConstraintViolation, http.StatusBadRequest, "constraint_violation"},
+		{services.ErrInvalidCacheKey, http.StatusBadRequest, "invalid_cache_key"},
+		{services.ErrCacheKeyNotFound, http.StatusNotFound, "cache_key_not_found"},
+		{services.ErrProtectedCachePrefix, http.StatusBadRequest, "protected_cache_prefix"},
+		{services.ErrInvalidIPRule, http.StatusBadRequest, "invalid_ip_rule"},
+		{services.ErrIPRuleExists, http.StatusConflict, "ip_rule_exists"},
+		{services.ErrIPRuleNotFound, http.StatusNotFound, "ip_rule_not_found"},
+		{services.ErrInvalidIPAddress, http.StatusBadRequest, "invalid_ip_address"},
+		{services.ErrIPBanNotFound, http.StatusNotFound, "ip_ban_not_found"},
+		{services.ErrIPFilterUnavailable, http.StatusServiceUnavailable, "ip_filter_unavailable"},
+		{services.ErrInvalidAuditFilter, http.StatusBadRequest, "invalid_audit_filter"},
+	}
+	covered := make(map[error]bool, len(tests))
+	for _, tt := range tests {
+		covered[tt.err] = true
+		for _, err := range []error{tt.err, fmt.Errorf("wrapped: %w", tt.err)} {
+			t.Run(err.Error(), func(t *testing.T) {
+				w, body := serveError(t, err)
+				if w.Code != tt.wantStatus || body.Status != tt.wantStatus {
+					t.Errorf("status = %d (body %d), want %d", w.Code, body.Status, tt.wantStatus)
+				}
+				if got := w.Header().Get("Content-Type"); got != middleware.ProblemContentType {
+					t.Errorf("Content-Type = %q, want %q", got, middleware.ProblemContentType)
+				}
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+				if body.Detail == "" || len(body.Errors) != 0 {
+					t.Errorf("detail = %q, errors = %v, want a detail and no field errors", body.Detail, body.Errors)
+				}
+			})
+		}
+	}
+	for _, p := range serviceProblems {
+		if !covered[p.err] {
+			t.Errorf("service error %q has no test case", p.err)
+		}
+	}
+}
+
+func TestErrorHandlerRendersFieldErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantErrors []middleware.FieldError // Message is only checked to be non-empty
+	}{
+		{"password too short", services.ErrPasswordTooShort, http.StatusBadRequest, middleware.CodeValidationFailed, []middleware.FieldError{{Field: "password", Code: "password_too_short"}}},
+		{"password without upper case", services.ErrPasswordNoUpper, http.StatusBadRequest, middleware.CodeValidationFailed, []middleware.FieldError{{Field: "password", Code: "password_no_upper"}}},
+		{"password without lower case", services.ErrPasswordNoLower, http.StatusBadRequest, middleware.CodeValidationFailed, []middleware.FieldError{{Field: "password", Code: "password_no_lower"}}},
+		{"password without number", services.ErrPasswordNoNumber, http.StatusBadRequest, middleware.CodeValidationFailed, []middleware.FieldError{{Field: "password", Code: "password_no_number"}}},
+		{"password without special character", services.ErrPasswordNoSpecial, http.StatusBadRequest, middleware.CodeValidationFailed, []middleware.FieldError{{Field: "password", Code: "password_no_special"}}},
+		{"problem passed as is", middleware.NewProblem(http.StatusForbidden, middleware.CodeForbidden, "Forbidden"), http.StatusForbidden, middleware.CodeForbidden, nil},
+		{"unexpected error", errors.New("connection reset for alice@example.com"), http.StatusInternalServerError, middleware.CodeInternal, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := serveError(t, tt.err)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != middleware.ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, middleware.ProblemContentType)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if len(body.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %+v, want %+v", body.Errors, tt.wantErrors)
+			}
+			for i, want := range tt.wantErrors {
+				got := body.Errors[i]
+				if got.Field != want.Field || got.Code != want.Code || got.Message == "" {
+					t.Errorf("errors[%d] = %+v, want field %q code %q with a message", i, got, want.Field, want.Code)
+				}
+			}
+			if tt.wantStatus == http.StatusInternalServerError && body.Detail != "Internal server error" {
+				t.Errorf("detail = %q, want the generic message", body.Detail)
+			}
+		})
+	}
+}
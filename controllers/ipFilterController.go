@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/services"
+)
+
+// IPRuleInput holds the data for adding an IP rule
+type IPRuleInput struct {
+	CIDR   string `json:"cidr" binding:"required,max=64"`
+	Action string `json:"action" binding:"required,oneof=allow deny"`
+	Note   string `json:"note" binding:"omitempty,max=200"`
+}
+
+// GetIPRules lists the IP allow/deny rules
+// @Summary      List IP rules
+// @Description  List IP allow/deny rules from configuration and the admin API (admin only)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}  "IP rules"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Forbidden"
+// @Failure      500  {object}  middleware.Problem  "Server error"
+// @Failure      503  {object}  middleware.Problem  "Cache backend cannot store IP rules and bans"
+// @Router       /admin/ip-rules [get]
+func GetIPRules(ipFilterService services.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := ipFilterService.ListRules(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"rules": rules})
+	}
+}
+
+// AddIPRule adds an IP allow/deny rule
+// @Summary      Add IP rule
+// @Description  Allow or deny an IP or CIDR range on every instance (admin only). Allow rules win over deny rules and bypass rate limits
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        rule  body      IPRuleInput  true  "IP rule"
+// @Success      201   {object}  map[string]interface{}  "Created rule"
+// @Failure      400   {object}  middleware.Problem  "Invalid rule"
+// @Failure      401   {object}  middleware.Problem  "Unauthorized"
+// @Failure      403   {object}  middleware.Problem  "Forbidden"
+// @Failure      409   {object}  middleware.Problem  "Rule already exists"
+// @Failure      500   {object}  middleware.Problem  "Server error"
+// @Failure      503   {object}  middleware.Problem  "Cache backend cannot store IP rules and bans"
+// @Router       /admin/ip-rules [post]
+func AddIPRule(ipFilterService services.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input IPRuleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+
+		rule, err := ipFilterService.AddRule(c.Request.Context(), input.CIDR, input.Action, input.Note)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// DeleteIPRule removes an admin-managed IP rule
+// @Summary      Delete IP rule
+// @Description  Remove an IP rule added through the admin API (admin only). Rules from configuration cannot be removed
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cidr    query     string  true  "IP or CIDR"
+// @Param        action  query     string  true  "allow or deny"
+// @Success      200     {object}  map[string]string  "Rule deleted"
+// @Failure      400     {object}  middleware.Problem  "Invalid rule"
+// @Failure      401     {object}  middleware.Problem  "Unauthorized"
+// @Failure      403     {object}  middleware.Problem  "Forbidden"
+// @Failure      404     {object}  middleware.Problem  "Rule not found"
+// @Failure      500     {object}  middleware.Problem  "Server error"
+// @Failure      503     {object}  middleware.Problem  "Cache backend cannot store IP rules and bans"
+// @Router       /admin/ip-rules [delete]
+func DeleteIPRule(ipFilterService services.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := ipFilterService.RemoveRule(c.Request.Context(), c.Query("cidr"), c.Query("action")); err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "IP rule deleted successfully"})
+	}
+}
+
+// GetIPBan returns the active ban for an IP
+// @Summary      Inspect IP ban
+// @Description  Get the active automatic ban for an IP (admin only). IPv6 addresses are grouped by prefix
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ip   path      string  true  "IP address"
+// @Success      200  {object}  map[string]interface{}  "Active ban"
+// @Failure      400  {object}  middleware.Problem  "Invalid IP address"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Forbidden"
+// @Failure      404  {object}  middleware.Problem  "IP is not banned"
+// @Failure      500  {object}  middleware.Problem  "Server error"
+// @Failure      503  {object}  middleware.Problem  "Cache backend cannot store IP rules and bans"
+// @Router       /admin/ip-bans/{ip} [get]
+func GetIPBan(ipFilterService services.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ban, err := ipFilterService.GetBan(c.Request.Context(), c.Param("ip"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, ban)
+	}
+}
+
+// DeleteIPBan lifts the active ban for an IP
+// @Summary      Lift IP ban
+// @Description  Lift an automatic ban early (admin only). Earlier bans still count towards escalation
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ip   path      string  true  "IP address"
+// @Success      200  {object}  map[string]string  "Ban lifted"
+// @Failure      400  {object}  middleware.Problem  "Invalid IP address"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Forbidden"
+// @Failure      404  {object}  middleware.Problem  "IP is not banned"
+// @Failure      500  {object}  middleware.Problem  "Server error"
+// @Failure      503  {object}  middleware.Problem  "Cache backend cannot store IP rules and bans"
+// @Router       /admin/ip-bans/{ip} [delete]
+func DeleteIPBan(ipFilterService services.IPFilterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := ipFilterService.LiftBan(c.Request.Context(), c.Param("ip")); err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "IP ban lifted successfully"})
+	}
+}
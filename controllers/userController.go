@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/middleware"
 	"github.com/leventeberry/goapi/models"
 	"github.com/leventeberry/goapi/services"
 )
@@ -75,9 +76,9 @@ func toUserResponseList(users []models.User) []UserResponse {
 // @Param        page       query     int     false  "Page number (default: 1)"
 // @Param        page_size  query     int     false  "Items per page (default: 10, max: 100)"
 // @Success      200        {object}  map[string]interface{}  "Paginated users response"
-// @Failure      400        {object}  map[string]string  "Invalid pagination parameters"
-// @Failure      401        {object}  map[string]string  "Unauthorized"
-// @Failure      500        {object}  map[string]string  "Server error"
+// @Failure      400        {object}  middleware.Problem  "Invalid pagination parameters"
+// @Failure      401        {object}  middleware.Problem  "Unauthorized"
+// @Failure      500        {object}  middleware.Problem  "Server error"
 // @Router       /users [get]
 func GetUsers(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -93,7 +94,7 @@ func GetUsers(userService services.UserService) gin.HandlerFunc {
 			if pageParam != "" {
 				parsedPage, err := strconv.Atoi(pageParam)
 				if err != nil || parsedPage < 1 {
-					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page parameter"})
+					c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeInvalidParameter, "Invalid page parameter"))
 					return
 				}
 				page = parsedPage
@@ -102,7 +103,7 @@ func GetUsers(userService services.UserService) gin.HandlerFunc {
 			if pageSizeParam != "" {
 				parsedPageSize, err := strconv.Atoi(pageSizeParam)
 				if err != nil || parsedPageSize < 1 {
-					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size parameter"})
+					c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeInvalidParameter, "Invalid page_size parameter"))
 					return
 				}
 				pageSize = parsedPageSize
@@ -115,7 +116,7 @@ func GetUsers(userService services.UserService) gin.HandlerFunc {
 
 			users, total, err := userService.GetAllUsersPaginated(c.Request.Context(), params)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+				c.Error(err)
 				return
 			}
 
@@ -141,7 +142,7 @@ func GetUsers(userService services.UserService) gin.HandlerFunc {
 		// No pagination parameters - return all users (backward compatibility)
 		users, err := userService.GetAllUsers(c.Request.Context())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+			c.Error(err)
 			return
 		}
 		c.JSON(http.StatusOK, toUserResponseList(users))
@@ -157,23 +158,23 @@ func GetUsers(userService services.UserService) gin.HandlerFunc {
 // @Security     BearerAuth
 // @Param        id   path      int  true  "User ID"
 // @Success      200  {object}  models.User  "User object"
-// @Failure      400  {object}  map[string]string  "Invalid user ID"
-// @Failure      401  {object}  map[string]string  "Unauthorized"
-// @Failure      404  {object}  map[string]string  "User not found"
-// @Failure      500  {object}  map[string]string  "Server error"
+// @Failure      400  {object}  middleware.Problem  "Invalid user ID"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      404  {object}  middleware.Problem  "User not found"
+// @Failure      500  {object}  middleware.Problem  "Server error"
 // @Router       /users/{id} [get]
 func GetUser(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.ParseInt(idParam, 10, 64)
 		if err != nil || id < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeInvalidParameter, "Invalid user ID"))
 			return
 		}
 
 		user, err := userService.GetUserByID(c.Request.Context(), int(id))
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
@@ -190,22 +191,22 @@ func GetUser(userService services.UserService) gin.HandlerFunc {
 // @Security     BearerAuth
 // @Param        user  body      CreateUserInput  true  "User data"
 // @Success      201   {object}  models.User  "Created user"
-// @Failure      400   {object}  map[string]string  "Invalid request"
-// @Failure      401   {object}  map[string]string  "Unauthorized"
-// @Failure      409   {object}  map[string]string  "Email already registered"
-// @Failure      500   {object}  map[string]string  "Server error"
+// @Failure      400   {object}  middleware.Problem  "Invalid request"
+// @Failure      401   {object}  middleware.Problem  "Unauthorized"
+// @Failure      409   {object}  middleware.Problem  "Email already registered"
+// @Failure      500   {object}  middleware.Problem  "Server error"
 // @Router       /users [post]
 func CreateUser(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input CreateUserInput
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Error(err).SetType(gin.ErrorTypeBind)
 			return
 		}
 
 		// Validate password strength
 		if err := services.ValidatePasswordStrength(input.Password); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err)
 			return
 		}
 
@@ -220,7 +221,7 @@ func CreateUser(userService services.UserService) gin.HandlerFunc {
 
 		user, err := userService.CreateUser(c.Request.Context(), createInput)
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
@@ -238,31 +239,31 @@ func CreateUser(userService services.UserService) gin.HandlerFunc {
 // @Param        id    path      int              true  "User ID"
 // @Param        user  body      UpdateUserInput  true  "User update data"
 // @Success      200   {object}  models.User  "Updated user"
-// @Failure      400   {object}  map[string]string  "Invalid request"
-// @Failure      401   {object}  map[string]string  "Unauthorized"
-// @Failure      404   {object}  map[string]string  "User not found"
-// @Failure      409   {object}  map[string]string  "Email already registered"
-// @Failure      500   {object}  map[string]string  "Server error"
+// @Failure      400   {object}  middleware.Problem  "Invalid request"
+// @Failure      401   {object}  middleware.Problem  "Unauthorized"
+// @Failure      404   {object}  middleware.Problem  "User not found"
+// @Failure      409   {object}  middleware.Problem  "Email already registered"
+// @Failure      500   {object}  middleware.Problem  "Server error"
 // @Router       /users/{id} [put]
 func UpdateUser(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.ParseInt(idParam, 10, 64)
 		if err != nil || id < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeInvalidParameter, "Invalid user ID"))
 			return
 		}
 
 		var input UpdateUserInput
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Error(err).SetType(gin.ErrorTypeBind)
 			return
 		}
 
 		// Validate password strength if password is being updated
 		if input.Password != nil {
 			if err := services.ValidatePasswordStrength(*input.Password); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				c.Error(err)
 				return
 			}
 		}
@@ -278,7 +279,7 @@ func UpdateUser(userService services.UserService) gin.HandlerFunc {
 
 		user, err := userService.UpdateUser(c.Request.Context(), int(id), updateInput)
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
@@ -295,24 +296,24 @@ func UpdateUser(userService services.UserService) gin.HandlerFunc {
 // @Security     BearerAuth
 // @Param        id   path      int  true  "User ID"
 // @Success      200  {object}  map[string]string  "User deleted successfully"
-// @Failure      400  {object}  map[string]string  "Invalid user ID"
-// @Failure      401  {object}  map[string]string  "Unauthorized"
-// @Failure      403  {object}  map[string]string  "Insufficient permissions"
-// @Failure      404  {object}  map[string]string  "User not found"
-// @Failure      500  {object}  map[string]string  "Server error"
+// @Failure      400  {object}  middleware.Problem  "Invalid user ID"
+// @Failure      401  {object}  middleware.Problem  "Unauthorized"
+// @Failure      403  {object}  middleware.Problem  "Insufficient permissions"
+// @Failure      404  {object}  middleware.Problem  "User not found"
+// @Failure      500  {object}  middleware.Problem  "Server error"
 // @Router       /users/{id} [delete]
 func DeleteUser(userService services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.ParseInt(idParam, 10, 64)
 		if err != nil || id < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			c.Error(middleware.NewProblem(http.StatusBadRequest, middleware.CodeInvalidParameter, "Invalid user ID"))
 			return
 		}
 
 		err = userService.DeleteUser(c.Request.Context(), int(id))
 		if err != nil {
-			handleServiceError(c, err)
+			c.Error(err)
 			return
 		}
 
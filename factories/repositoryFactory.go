@@ -1,6 +1,7 @@
 package factories
 
 import (
+	"github.com/leventeberry/goapi/config"
 	"github.com/leventeberry/goapi/repositories"
 	"gorm.io/gorm"
 )
@@ -8,18 +9,31 @@ import (
 // RepositoryFactory creates repository instances
 // Implements Factory Pattern for repository creation
 type RepositoryFactory struct {
-	db *gorm.DB
+	resolver *repositories.DBResolver
 }
 
 // NewRepositoryFactory creates a new repository factory
-func NewRepositoryFactory(db *gorm.DB) *RepositoryFactory {
+// Optional replicas receive replica-eligible reads; writes always use db
+// Each repository call is bounded by the configured DB_QUERY_TIMEOUT
+func NewRepositoryFactory(db *gorm.DB, replicas ...*gorm.DB) *RepositoryFactory {
+	resolver := repositories.NewDBResolver(db, replicas...).
+		WithQueryTimeout(config.Get().Database.QueryTimeout)
 	return &RepositoryFactory{
-		db: db,
+		resolver: resolver,
 	}
 }
 
 // CreateUserRepository creates a UserRepository instance
 func (f *RepositoryFactory) CreateUserRepository() repositories.UserRepository {
-	return repositories.NewUserRepository(f.db)
+	return repositories.NewUserRepository(f.resolver)
 }
 
+// CreateAuditRepository creates an AuditRepository instance
+func (f *RepositoryFactory) CreateAuditRepository() repositories.AuditRepository {
+	return repositories.NewAuditRepository(f.resolver)
+}
+
+// CreateTransactionManager creates a TransactionManager instance
+func (f *RepositoryFactory) CreateTransactionManager() repositories.TransactionManager {
+	return repositories.NewTransactionManager(f.resolver)
+}
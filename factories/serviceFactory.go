@@ -2,6 +2,10 @@ package factories
 
 import (
 	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/logger"
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/models"
 	"github.com/leventeberry/goapi/repositories"
 	"github.com/leventeberry/goapi/services"
 )
@@ -9,25 +13,54 @@ import (
 // ServiceFactory creates service instances
 // Implements Factory Pattern for service creation
 type ServiceFactory struct {
-	userRepo repositories.UserRepository
-	cache    cache.Cache
+	userRepo  repositories.UserRepository
+	auditRepo repositories.AuditRepository
+	txManager repositories.TransactionManager
+	cache     cache.Cache
 }
 
 // NewServiceFactory creates a new service factory
-func NewServiceFactory(userRepo repositories.UserRepository, cacheClient cache.Cache) *ServiceFactory {
+func NewServiceFactory(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, txManager repositories.TransactionManager, cacheClient cache.Cache) *ServiceFactory {
 	return &ServiceFactory{
-		userRepo: userRepo,
-		cache:    cacheClient,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		txManager: txManager,
+		cache:     cacheClient,
 	}
 }
 
-// CreateUserService creates a UserService instance
+// CreateUserService creates a UserService instance, wrapped with tracing spans
 func (f *ServiceFactory) CreateUserService() services.UserService {
-	return services.NewUserService(f.userRepo, f.cache)
+	return services.NewTracedUserService(services.NewUserService(f.userRepo, f.auditRepo, f.txManager, f.cache, userCodec()))
 }
 
-// CreateAuthService creates an AuthService instance
+// CreateAuthService creates an AuthService instance, wrapped with tracing spans
 func (f *ServiceFactory) CreateAuthService() services.AuthService {
-	return services.NewAuthService(f.userRepo)
+	return services.NewTracedAuthService(services.NewAuthService(f.userRepo, f.auditRepo, f.txManager, f.cache))
 }
 
+// CreateAuditService creates an AuditService instance
+func (f *ServiceFactory) CreateAuditService() services.AuditService {
+	return services.NewAuditService(f.auditRepo)
+}
+
+// CreateCacheService creates a CacheService instance
+func (f *ServiceFactory) CreateCacheService() services.CacheService {
+	return services.NewCacheService(f.cache)
+}
+
+// CreateIPFilterService creates an IPFilterService instance for the given filter
+func (f *ServiceFactory) CreateIPFilterService(filter *middleware.IPFilter) services.IPFilterService {
+	return services.NewIPFilterService(filter)
+}
+
+// userCodec builds the user cache codec from CACHE_CODEC
+// Users are always cached through the CachedUser DTO so PassHash never reaches the cache
+func userCodec() cache.Codec[models.User] {
+	inner, err := cache.NewCodec[cache.CachedUser](config.Get().Cache.Codec)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("Falling back to JSON cache codec")
+		inner = cache.JSONCodec[cache.CachedUser]{}
+	}
+	return cache.NewUserCodec(inner)
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/leventeberry/goapi/cache"
+	"gorm.io/gorm"
+)
+
+// DBChecker pings the connection pool behind db
+func DBChecker(name string, db *gorm.DB) Checker {
+	return NewChecker(name, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get database connection: %w", err)
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// CacheChecker pings the cache backend (always healthy for the memory and no-op backends)
+func CacheChecker(c cache.Cache) Checker {
+	return NewChecker("cache", c.Ping)
+}
+
+// MigrationsChecker verifies that the tables for every model exist, i.e. AutoMigrate has run
+func MigrationsChecker(db *gorm.DB, models ...any) Checker {
+	return NewChecker("migrations", func(ctx context.Context) error {
+		migrator := db.WithContext(ctx).Migrator()
+		var missing []string
+		for _, model := range models {
+			if !migrator.HasTable(model) {
+				missing = append(missing, fmt.Sprintf("%T", model))
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing tables for %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+}
+
+// DiskChecker fails when the filesystem holding path has less than minFreeBytes available
+func DiskChecker(path string, minFreeBytes uint64) Checker {
+	return NewChecker("disk", func(ctx context.Context) error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("only %d MB free on %s (minimum %d MB)", free>>20, path, minFreeBytes>>20)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe identifies which endpoint a check belongs to
+type Probe string
+
+// Probes served by the API, following the Kubernetes conventions
+const (
+	// Liveness fails only when the process must be restarted; dependencies never belong here
+	Liveness Probe = "livez"
+	// Readiness fails when the instance should stop receiving traffic
+	Readiness Probe = "readyz"
+	// Startup fails until initialization (migrations, first connections) has completed
+	Startup Probe = "startupz"
+)
+
+// Check statuses reported per check and overall
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// StatusWarn marks a failed optional check; it is reported but does not fail the probe
+	StatusWarn = "warn"
+)
+
+// Checker verifies one dependency
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewChecker creates a Checker named name that runs fn
+func NewChecker(name string, fn func(ctx context.Context) error) CheckerFunc {
+	return CheckerFunc{name: name, fn: fn}
+}
+
+// Name implements Checker
+func (c CheckerFunc) Name() string { return c.name }
+
+// Check implements Checker
+func (c CheckerFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	// CheckedAt is when the check last ran; results are reused until they are older than the cache TTL
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the outcome of a probe
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// OK reports whether the probe passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// registeredCheck is a checker with its probes and cached result
+type registeredCheck struct {
+	checker  Checker
+	probes   []Probe
+	required bool
+
+	mu     sync.Mutex
+	result CheckResult
+}
+
+// Registry runs the registered checks for each probe
+// Results are cached for cacheTTL so frequent probes from many sources don't hammer dependencies
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*registeredCheck
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates an empty registry; timeout bounds each individual check
+func NewRegistry(cacheTTL, timeout time.Duration) *Registry {
+	return &Registry{cacheTTL: cacheTTL, timeout: timeout}
+}
+
+// Register adds a required checker to the given probes; a failure fails the probe
+func (r *Registry) Register(checker Checker, probes ...Probe) {
+	r.register(checker, true, probes)
+}
+
+// RegisterOptional adds a checker whose failure is reported as "warn" without failing the probe
+// Use it for dependencies the API can degrade without (cache, read replicas)
+func (r *Registry) RegisterOptional(checker Checker, probes ...Probe) {
+	r.register(checker, false, probes)
+}
+
+func (r *Registry) register(checker Checker, required bool, probes []Probe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &registeredCheck{checker: checker, probes: probes, required: required})
+}
+
+// MarkStarted records that initialization finished; the startup probe fails until then
+func (r *Registry) MarkStarted() {
+	r.started.Store(true)
+}
+
+// MarkShuttingDown makes the readiness probe fail so load balancers drain the instance before it stops
+func (r *Registry) MarkShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Run executes (or reuses cached results of) the checks registered for probe
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	r.mu.RLock()
+	var checks []*registeredCheck
+	for _, check := range r.checks {
+		for _, p := range check.probes {
+			if p == probe {
+				checks = append(checks, check)
+				break
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make([]CheckResult, len(checks))}
+
+	switch {
+	case probe == Readiness && r.shuttingDown.Load():
+		report.Status = StatusFail
+		report.Checks = append(report.Checks, CheckResult{Name: "shutdown", Status: StatusFail, Error: "server is shutting down", CheckedAt: time.Now().UTC()})
+	case probe == Startup && !r.started.Load():
+		report.Status = StatusFail
+		report.Checks = append(report.Checks, CheckResult{Name: "startup", Status: StatusFail, Error: "initialization has not completed", CheckedAt: time.Now().UTC()})
+	}
+
+	// Checks run concurrently so one slow dependency doesn't add up with the others
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = r.runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runCheck returns the cached result for check, running it again once the cache has expired
+// Concurrent callers wait for a single run instead of each hitting the dependency
+func (r *Registry) runCheck(ctx context.Context, check *registeredCheck) CheckResult {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if !check.result.CheckedAt.IsZero() && time.Since(check.result.CheckedAt) < r.cacheTTL {
+		return check.result
+	}
+
+	// Detached from the request so a client hanging up does not cache a failure
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.checker.Check(ctx)
+	result := CheckResult{
+		Name:       check.checker.Name(),
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt:  start.UTC(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Status = StatusFail
+		if !check.required {
+			result.Status = StatusWarn
+		}
+	}
+	check.result = result
+	return result
+}
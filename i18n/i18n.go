@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	deTranslations "github.com/go-playground/validator/v10/translations/de"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	"golang.org/x/text/language"
+)
+
+// Supported locales; English is the source language of every message written in code
+const (
+	English = "en"
+	French  = "fr"
+	German  = "de"
+)
+
+// locales lists the supported locales in matcher order; the first is the default
+var locales = []string{English, French, German}
+
+// matcher picks the supported locale closest to the client's preferences
+var matcher = language.NewMatcher([]language.Tag{language.English, language.French, language.German})
+
+// catalogFiles holds one JSON catalog per non-English locale, mapping English messages to translations
+//
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs maps locale -> English message -> translation
+var catalogs = loadCatalogs()
+
+// universal holds the validator translators for every supported locale
+var universal = ut.New(en.New(), en.New(), fr.New(), de.New())
+
+// Match returns the supported locale that best fits an Accept-Language header
+// Unknown, empty or malformed headers get English
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+	_, index, _ := matcher.Match(tags...)
+	return locales[index]
+}
+
+// Message translates an English message into locale
+// The English message is returned as is when the catalog has no translation
+func Message(locale, message string) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// RegisterValidator adds the validator's built-in messages for every supported locale to v
+func RegisterValidator(v *validator.Validate) error {
+	registrations := map[string]func(*validator.Validate, ut.Translator) error{
+		English: enTranslations.RegisterDefaultTranslations,
+		French:  frTranslations.RegisterDefaultTranslations,
+		German:  deTranslations.RegisterDefaultTranslations,
+	}
+	for locale, register := range registrations {
+		translator, _ := universal.GetTranslator(locale)
+		if err := register(v, translator); err != nil {
+			return fmt.Errorf("failed to register %s validation messages: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// FieldError returns the message for a failed validation rule in locale
+// ok is false when the locale has no message for the rule
+func FieldError(locale string, fieldErr validator.FieldError) (message string, ok bool) {
+	translator, _ := universal.GetTranslator(locale)
+	message = fieldErr.Translate(translator)
+	// Translate falls back to the raw validator error, which is not fit for clients
+	return message, message != fieldErr.Error()
+}
+
+// loadCatalogs parses the embedded catalogs; a malformed catalog is a build mistake, so it panics
+func loadCatalogs() map[string]map[string]string {
+	files, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read catalogs: %v", err))
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read catalog %s: %v", file.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = messages
+	}
+	return loaded
+}
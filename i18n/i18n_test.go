@@ -0,0 +1,179 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCatalogParity(t *testing.T) {
+	for _, locale := range locales[1:] {
+		if _, ok := catalogs[locale]; !ok {
+			t.Errorf("no catalog for supported locale %s", locale)
+		}
+	}
+	for locale := range catalogs {
+		if !slices.Contains(locales, locale) {
+			t.Errorf("catalog %s.json is not a supported locale", locale)
+		}
+	}
+	for locale, messages := range catalogs {
+		for other, otherMessages := range catalogs {
+			for message := range otherMessages {
+				if _, ok := messages[message]; !ok {
+					t.Errorf("%s catalog is missing %q, which the %s catalog translates", locale, message, other)
+				}
+			}
+		}
+		for message, translated := range messages {
+			if strings.TrimSpace(translated) == "" {
+				t.Errorf("%s catalog has an empty translation for %q", locale, message)
+			}
+		}
+	}
+}
+
+// TestCatalogsCoverEmittedMessages fails when code emits an English message a catalog does not translate
+// Messages are collected from the source: NewProblem titles and details, the service problem table and password rule errors
+func TestCatalogsCoverEmittedMessages(t *testing.T) {
+	messages := emittedMessages(t, "..")
+	if len(messages) < 20 {
+		t.Fatalf("found only %d emitted messages; the source scan is broken", len(messages))
+	}
+	for _, locale := range locales[1:] {
+		for _, message := range messages {
+			if _, ok := catalogs[locale][message]; !ok {
+				t.Errorf("%s catalog is missing %q", locale, message)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"fr-CH, fr;q=0.9, en;q=0.8", French},
+		{"de-DE", German},
+		{"en-US,en;q=0.9", English},
+		{"es-ES", English},
+		{"", English},
+		{"not a language;;q=x", English},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Match(%q) = %s, want %s", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+// emittedMessages parses the non-test Go files under root and returns the English messages sent to clients
+func emittedMessages(t *testing.T, root string) []string {
+	t.Helper()
+	statusTexts := make(map[string]string)
+	for code := 100; code < 600; code++ {
+		if text := http.StatusText(code); text != "" {
+			statusTexts["Status"+strings.NewReplacer(" ", "", "-", "", "'", "").Replace(text)] = text
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(file string, expr ast.Expr, isStatus bool) {
+		switch e := expr.(type) {
+		case *ast.SelectorExpr:
+			if !isStatus {
+				return
+			}
+			if text, ok := statusTexts[e.Sel.Name]; ok {
+				seen[text] = true
+			} else if strings.HasPrefix(e.Sel.Name, "Status") {
+				t.Errorf("%s: unknown status constant %s", file, e.Sel.Name)
+			}
+		case *ast.BasicLit:
+			if message, err := strconv.Unquote(e.Value); err == nil && e.Kind == token.STRING && !isStatus {
+				seen[message] = true
+			}
+		}
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "docs" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, src, 0)
+		if err != nil {
+			return err
+		}
+		passwordRules := filepath.Base(path) == "validation.go" && file.Name.Name == "services"
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				switch name := calleeName(n.Fun); {
+				case name == "NewProblem" && len(n.Args) == 3:
+					add(path, n.Args[0], true)
+					add(path, n.Args[2], false)
+				case name == "New" && passwordRules && len(n.Args) == 1:
+					add(path, n.Args[0], false)
+				}
+			case *ast.CompositeLit:
+				// serviceProblems rows: {err, status, code, detail}
+				if len(n.Elts) == 4 && isStatusConstant(n.Elts[1]) {
+					add(path, n.Elts[1], true)
+					add(path, n.Elts[3], false)
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan the source: %v", err)
+	}
+
+	messages := make([]string, 0, len(seen))
+	for message := range seen {
+		messages = append(messages, message)
+	}
+	slices.Sort(messages)
+	return messages
+}
+
+// calleeName returns the name of the called function, without its package
+func calleeName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
+
+// isStatusConstant reports whether expr is an http.Status* constant
+func isStatusConstant(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "http" && strings.HasPrefix(sel.Sel.Name, "Status")
+}
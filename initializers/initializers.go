@@ -3,13 +3,19 @@ package initializers
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/leventeberry/goapi/cache"
 	"github.com/leventeberry/goapi/config"
 	"github.com/leventeberry/goapi/logger"
 	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/tracing"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -18,9 +24,18 @@ import (
 // DB is the global database connection
 var DB *gorm.DB
 
+// ReplicaDBs are the global read replica connections (empty when none are configured)
+var ReplicaDBs []*gorm.DB
+
 // RedisClient is the global Redis client connection
 var RedisClient *redis.Client
 
+// cacheClosers are cache clients with background workers to stop on shutdown
+var cacheClosers []io.Closer
+
+// cacheStats collects the stats of every cache client, so all clients report into one set of counters
+var cacheStats = cache.NewStats()
+
 // Init loads environment variables, connects to the database, and runs migrations.
 func Init() {
 	loadEnv()
@@ -28,27 +43,33 @@ func Init() {
 	// Load centralized configuration (must be after loadEnv)
 	config.Load()
 	connectDB()
+	connectReplicas()
 	migrateDB()
 	connectRedis()
 }
 
-// loadEnv reads .env file into environment, if present
+// loadEnv reads .env file into environment, if present, and applies the LOG_* settings from it
 func loadEnv() {
-	if err := godotenv.Load(); err != nil {
+	err := godotenv.Load()
+	logger.Configure()
+	if err != nil {
 		logger.Log.Info().Msg("No .env file found; relying on environment variables")
 	}
 }
 
 // validateEnv checks that all required environment variables are set
+// The individual DB_* variables are not required when DATABASE_URL is provided
 func validateEnv() {
 	requiredVars := map[string]string{
-		"DB_USER":    "Database username",
-		"DB_PASS":    "Database password",
-		"DB_HOST":    "Database host (e.g., localhost)",
-		"DB_PORT":    "Database port (e.g., 5432)",
-		"DB_NAME":    "Database name",
 		"JWT_SECRET": "JWT secret key for token signing",
 	}
+	if os.Getenv("DATABASE_URL") == "" {
+		requiredVars["DB_USER"] = "Database username"
+		requiredVars["DB_PASS"] = "Database password"
+		requiredVars["DB_HOST"] = "Database host (e.g., localhost)"
+		requiredVars["DB_PORT"] = "Database port (e.g., 5432)"
+		requiredVars["DB_NAME"] = "Database name"
+	}
 
 	var missing []string
 	for key, description := range requiredVars {
@@ -66,42 +87,176 @@ func validateEnv() {
 }
 
 // connectDB opens a PostgreSQL connection using GORM
+// Retries with exponential backoff so the app tolerates Postgres starting late (e.g. in docker-compose)
 func connectDB() {
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	if port == "" {
-		port = "5432"
-	}
-	name := os.Getenv("DB_NAME")
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", host, user, pass, name, port)
+	cfg := config.Get().Database
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = openDB(buildDSN(), "primary")
 	if err != nil {
-		logger.Log.Fatal().Err(err).Msg("Failed to connect to database")
+		logger.Log.Fatal().Err(err).Int("attempts", cfg.ConnectRetries+1).Msg("Failed to connect to database")
 	}
-	logger.Log.Info().Msg("Database connection established")
+
+	logger.Log.Info().
+		Int("max_open_conns", cfg.MaxOpenConns).
+		Int("max_idle_conns", cfg.MaxIdleConns).
+		Msg("Database connection established")
 }
 
-// migrateDB runs AutoMigrate on all models
+// connectReplicas opens connections to the configured read replicas
+// A replica that cannot be reached is skipped; reads then fall back to the remaining replicas or the primary
+func connectReplicas() {
+	cfg := config.Get().Database
+	for i, replicaURL := range cfg.ReplicaURLs {
+		// Numbered by position among the connected replicas, like the replica-N metrics labels in main.go
+		role := fmt.Sprintf("replica-%d", len(ReplicaDBs)+1)
+		replica, err := openDB(withStatementTimeout(replicaURL, cfg.StatementTimeout), role)
+		if err != nil {
+			logger.Log.Warn().Err(err).Int("replica", i).Msg("Failed to connect to read replica, skipping it")
+			continue
+		}
+		ReplicaDBs = append(ReplicaDBs, replica)
+	}
+	if len(cfg.ReplicaURLs) > 0 {
+		logger.Log.Info().
+			Int("configured", len(cfg.ReplicaURLs)).
+			Int("connected", len(ReplicaDBs)).
+			Msg("Read replica connections established")
+	}
+}
+
+// openDB connects to the given DSN with retries and applies the configured pool settings
+func openDB(dsn, role string) (*gorm.DB, error) {
+	cfg := config.Get().Database
+
+	var (
+		db  *gorm.DB
+		err error
+	)
+	backoff := cfg.ConnectRetryBackoff
+	for attempt := 0; ; attempt++ {
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+		logger.Log.Warn().
+			Err(err).
+			Str("role", role).
+			Int("attempt", attempt+1).
+			Int("max_attempts", cfg.ConnectRetries+1).
+			Dur("retry_in", backoff).
+			Msg("Database not reachable, retrying")
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	// Every query becomes a child span of the service call that issued it
+	if err := db.Use(tracing.NewGormPlugin(role)); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection pool: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// maxConnectBackoff caps the delay between database connection attempts
+const maxConnectBackoff = 30 * time.Second
+
+// buildDSN returns the PostgreSQL connection string from configuration
+// DATABASE_URL takes precedence; otherwise the DSN is assembled from the DB_* settings
+func buildDSN() string {
+	cfg := config.Get().Database
+
+	if cfg.URL != "" {
+		return withStatementTimeout(cfg.URL, cfg.StatementTimeout)
+	}
+
+	params := []string{
+		"host=" + quoteDSNValue(cfg.Host),
+		"user=" + quoteDSNValue(cfg.User),
+		"password=" + quoteDSNValue(cfg.Password),
+		"dbname=" + quoteDSNValue(cfg.Name),
+		"port=" + quoteDSNValue(cfg.Port),
+		"sslmode=" + cfg.SSLMode,
+		"TimeZone=UTC",
+	}
+	if cfg.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+quoteDSNValue(cfg.SSLRootCert))
+	}
+	if cfg.SSLCert != "" {
+		params = append(params, "sslcert="+quoteDSNValue(cfg.SSLCert))
+	}
+	if cfg.SSLKey != "" {
+		params = append(params, "sslkey="+quoteDSNValue(cfg.SSLKey))
+	}
+	if cfg.StatementTimeout > 0 {
+		params = append(params, fmt.Sprintf("statement_timeout=%d", cfg.StatementTimeout.Milliseconds()))
+	}
+	return strings.Join(params, " ")
+}
+
+// quoteDSNValue quotes a keyword/value DSN value so passwords with spaces or quotes survive
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// withStatementTimeout adds statement_timeout to a URL-style DSN unless it is already set
+func withStatementTimeout(dsn string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		logger.Log.Warn().Msg("Connection string is not a URL; DB_STATEMENT_TIMEOUT is ignored for it")
+		return dsn
+	}
+	query := u.Query()
+	if query.Get("statement_timeout") == "" {
+		query.Set("statement_timeout", fmt.Sprintf("%d", timeout.Milliseconds()))
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// migrateDB runs AutoMigrate on all models and installs the audit_events append-only triggers
 func migrateDB() {
-	if err := DB.AutoMigrate(
-		&models.User{},
-		// add future models here, e.g. &controllers.Profile{}, &controllers.Order{},
-	); err != nil {
+	if err := DB.AutoMigrate(models.Migrated()...); err != nil {
 		logger.Log.Fatal().Err(err).Msg("Failed to run database migrations")
 	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range models.AuditEventTriggerSQL {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Failed to create the audit_events append-only triggers")
+	}
 	logger.Log.Info().Msg("Database migrations completed")
 }
 
-// connectRedis opens a Redis connection if Redis is enabled
-// Redis configuration is optional - if REDIS_ENABLED is not "true", Redis will not be connected
+// connectRedis opens a Redis connection if the Redis cache backend is selected
+// Redis configuration is optional - unless CACHE_BACKEND is "redis" or "tiered" (or REDIS_ENABLED is "true"), Redis will not be connected
 func connectRedis() {
-	enabled := os.Getenv("REDIS_ENABLED")
-	if enabled != "true" {
-		logger.Log.Info().Msg("Redis is disabled (REDIS_ENABLED != true)")
+	if backend := config.Get().Cache.Backend; backend != "redis" && backend != "tiered" {
+		logger.Log.Info().Str("cache_backend", backend).Msg("Redis is disabled")
 		return
 	}
 
@@ -126,6 +281,12 @@ func connectRedis() {
 		DB:       0, // Default DB
 	})
 
+	// Record a span for every Redis command issued by the cache and rate limiter
+	// Command arguments are left out of spans since cached values hold emails and user data
+	if err := redisotel.InstrumentTracing(RedisClient, redisotel.WithDBStatement(false)); err != nil {
+		logger.Log.Warn().Err(err).Msg("Failed to enable Redis tracing")
+	}
+
 	// Test connection
 	ctx := context.Background()
 	_, err := RedisClient.Ping(ctx).Result()
@@ -138,19 +299,45 @@ func connectRedis() {
 	logger.Log.Info().Str("address", addr).Msg("Redis connection established")
 }
 
-// GetCacheClient returns a cache client instance
-// Returns Redis cache if Redis is available, otherwise returns no-op cache
+// GetCacheClient returns a cache client instance for the configured CACHE_BACKEND
+// Returns Redis cache (optionally fronted by a local L1) if Redis is available,
+// an in-memory LRU cache for "memory", otherwise returns no-op cache
 // This centralizes cache client creation logic
+// Every backend is wrapped with stats collection (see cache.StatsOf); all clients share the same Stats
 func GetCacheClient() cache.Cache {
-	if RedisClient != nil {
+	return cache.NewInstrumentedCache(newCacheBackend(), cacheStats)
+}
+
+// newCacheBackend creates the cache implementation selected by CACHE_BACKEND
+func newCacheBackend() cache.Cache {
+	cfg := config.Get().Cache
+	switch {
+	case cfg.Backend == "redis" && RedisClient != nil:
 		return cache.NewRedisCache(RedisClient)
+	case cfg.Backend == "tiered" && RedisClient != nil:
+		tiered := cache.NewTieredCache(RedisClient, cfg.MemoryMaxEntries, cfg.L1TTL)
+		if closer, ok := tiered.(io.Closer); ok {
+			cacheClosers = append(cacheClosers, closer)
+		}
+		return tiered
+	case cfg.Backend == "memory":
+		return cache.NewMemoryCache(cfg.MemoryMaxEntries)
+	default:
+		return cache.NewNoOpCache()
 	}
-	return cache.NewNoOpCache()
 }
 
 // CloseRedis closes the Redis connection if it exists
 // Should be called on application shutdown for graceful cleanup
 func CloseRedis() {
+	// Stop cache background workers (e.g. pub/sub subscribers) before the connection goes away
+	for _, closer := range cacheClosers {
+		if err := closer.Close(); err != nil {
+			logger.Log.Error().Err(err).Msg("Error closing cache client")
+		}
+	}
+	cacheClosers = nil
+
 	if RedisClient != nil {
 		if err := RedisClient.Close(); err != nil {
 			logger.Log.Error().Err(err).Msg("Error closing Redis connection")
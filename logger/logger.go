@@ -1,36 +1,154 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Log is the global logger instance
-// It is initialized on package import with sensible defaults
+// It is initialized on package import with sensible defaults, and rebuilt by Configure once .env is loaded
 var Log zerolog.Logger
 
+// Log formats supported by LOG_FORMAT
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// closer is the current log file, closed by Close or when Configure replaces it
+var closer io.Closer
+
 func init() {
-	// Use human-readable console output for development
-	output := zerolog.ConsoleWriter{Out: os.Stderr}
+	// zerolog.Ctx falls back to the global logger for contexts without a request-scoped logger
+	zerolog.DefaultContextLogger = &Log
+
+	Configure()
+}
+
+// Configure builds the global logger from the LOG_* environment variables
+// Settings are read from the environment directly because the config package itself logs through this package
+func Configure() {
+	var out io.Writer
+	var file io.Closer
+	switch output := os.Getenv("LOG_OUTPUT"); output {
+	case "", "stderr":
+		out = os.Stderr
+	case "stdout":
+		out = os.Stdout
+	default:
+		// Any other value is a file path, rotated by size and pruned by age/count
+		rotator := &lumberjack.Logger{
+			Filename:   output,
+			MaxSize:    envInt("LOG_FILE_MAX_SIZE_MB", 100),
+			MaxBackups: envInt("LOG_FILE_MAX_BACKUPS", 5),
+			MaxAge:     envInt("LOG_FILE_MAX_AGE_DAYS", 30),
+			Compress:   os.Getenv("LOG_FILE_COMPRESS") != "false",
+		}
+		out, file = rotator, rotator
+	}
+
+	// Console output is human-readable but slow; JSON is the default in release mode for log pipelines
+	format := os.Getenv("LOG_FORMAT")
+	if format == "" {
+		format = FormatConsole
+		if os.Getenv("GIN_MODE") == "release" {
+			format = FormatJSON
+		}
+	}
+	if format == FormatConsole {
+		out = zerolog.ConsoleWriter{Out: out, NoColor: file != nil}
+	}
+
+	// Redaction runs on the serialized JSON before it reaches the console writer or the file
+	if os.Getenv("LOG_REDACT") != "false" {
+		out = NewRedactingWriter(out)
+	}
+
+	// The level is global rather than per logger, so SetLevel also reaches request-scoped loggers already created
+	zerolog.SetGlobalLevel(ParseLevel(os.Getenv("LOG_LEVEL")))
+
+	logger := zerolog.New(out).With().
+		Timestamp().
+		Logger()
+
+	// Sampling keeps the first LOG_SAMPLE_BURST info/debug logs per LOG_SAMPLE_PERIOD,
+	// then one in LOG_SAMPLE_EVERY; warnings and errors are never sampled
+	if burst := envInt("LOG_SAMPLE_BURST", 0); burst > 0 {
+		sampler := &zerolog.BurstSampler{
+			Burst:       uint32(burst),
+			Period:      envDuration("LOG_SAMPLE_PERIOD", time.Second),
+			NextSampler: &zerolog.BasicSampler{N: uint32(envInt("LOG_SAMPLE_EVERY", 100))},
+		}
+		logger = logger.Sample(zerolog.LevelSampler{DebugSampler: sampler, InfoSampler: sampler})
+	}
 
-	// Set global log level from environment variable (default: info)
-	logLevel := os.Getenv("LOG_LEVEL")
-	var level zerolog.Level
-	switch logLevel {
+	Log = logger
+
+	if closer != nil {
+		closer.Close()
+	}
+	closer = file
+}
+
+// Close flushes and closes the log file, if logging to one
+func Close() error {
+	if closer == nil {
+		return nil
+	}
+	return closer.Close()
+}
+
+// Level returns the current log level
+func Level() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+// SetLevel changes the log level at runtime (e.g. "debug" while investigating an incident)
+func SetLevel(value string) error {
+	level, err := zerolog.ParseLevel(value)
+	if err != nil || value == "" {
+		return fmt.Errorf("invalid log level %q", value)
+	}
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
+
+// ParseLevel converts a LOG_LEVEL value to a zerolog level (default: info)
+func ParseLevel(value string) zerolog.Level {
+	switch value {
 	case "debug":
-		level = zerolog.DebugLevel
+		return zerolog.DebugLevel
 	case "info":
-		level = zerolog.InfoLevel
+		return zerolog.InfoLevel
 	case "warn", "warning":
-		level = zerolog.WarnLevel
+		return zerolog.WarnLevel
 	case "error":
-		level = zerolog.ErrorLevel
+		return zerolog.ErrorLevel
 	default:
-		level = zerolog.InfoLevel
+		return zerolog.InfoLevel
 	}
+}
 
-	Log = zerolog.New(output).Level(level).With().
-		Timestamp().
-		Logger()
+// envInt reads a non-negative integer environment variable, ignoring invalid values
+func envInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// envDuration reads a positive duration environment variable, ignoring invalid values
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
 }
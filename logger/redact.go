@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"io"
+	"regexp"
+)
+
+// Redacted replaces secret values in log output
+const Redacted = "[REDACTED]"
+
+var (
+	// emailPattern matches email addresses; the local part is masked and the domain kept for debugging
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+	// jwtPattern matches JSON Web Tokens (three base64url segments, the first an encoded JSON header)
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+
+	// bearerPattern matches Authorization header values that are not JWTs
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/\-]+=*`)
+
+	// secretParamPattern matches secret query/form parameters, e.g. ?password=hunter2&page=1
+	secretParamPattern = regexp.MustCompile(`(?i)([?&](?:password|pass|token|access_token|refresh_token|api_key|secret)=)[^&"\s]*`)
+
+	// secretFieldPattern matches JSON fields whose value is a secret, e.g. "password":"hunter2"
+	// The value may contain escaped quotes, e.g. "password":"hun\"ter2"
+	secretFieldPattern = regexp.MustCompile(`(?i)("(?:` + secretFieldNames + `)"\s*:\s*")(?:[^"\\]|\\.)*(")`)
+
+	// escapedSecretFieldPattern matches secret fields of JSON logged as a string, e.g. "body":"{\"password\":\"hunter2\"}"
+	// Inside the string the field's quotes are \" and the value's own escapes are doubled
+	escapedSecretFieldPattern = regexp.MustCompile(`(?i)(\\"(?:` + secretFieldNames + `)\\"\s*:\s*\\")(?:[^"\\]|\\\\\\"|\\\\\\\\|\\\\[^"\\])*(\\")`)
+)
+
+// secretFieldNames lists the JSON fields whose values are always redacted
+const secretFieldNames = `password|pass_hash|token|access_token|refresh_token|api_key|secret|authorization`
+
+// Redact masks emails, tokens and secret parameters in a serialized log line
+func Redact(line []byte) []byte {
+	line = secretFieldPattern.ReplaceAll(line, []byte("${1}"+Redacted+"${2}"))
+	line = escapedSecretFieldPattern.ReplaceAll(line, []byte("${1}"+Redacted+"${2}"))
+	line = secretParamPattern.ReplaceAll(line, []byte("${1}"+Redacted))
+	line = jwtPattern.ReplaceAll(line, []byte(Redacted))
+	line = bearerPattern.ReplaceAll(line, []byte("${1}"+Redacted))
+	line = emailPattern.ReplaceAll(line, []byte("${1}***@${2}"))
+	return line
+}
+
+// redactingWriter applies Redact to every log line before passing it on
+type redactingWriter struct {
+	out io.Writer
+}
+
+// NewRedactingWriter wraps out so that sensitive values never reach the log destination
+func NewRedactingWriter(out io.Writer) io.Writer {
+	return redactingWriter{out: out}
+}
+
+// Write implements io.Writer; zerolog writes exactly one event per call
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.out.Write(Redact(p)); err != nil {
+		return 0, err
+	}
+	// Report the original length so zerolog doesn't treat a shorter line as a short write
+	return len(p), nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -9,12 +11,16 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
 	"github.com/leventeberry/goapi/container"
 	"github.com/leventeberry/goapi/docs"
 	"github.com/leventeberry/goapi/initializers"
 	"github.com/leventeberry/goapi/logger"
+	"github.com/leventeberry/goapi/metrics"
 	"github.com/leventeberry/goapi/middleware"
 	"github.com/leventeberry/goapi/routes"
+	"github.com/leventeberry/goapi/tracing"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -48,21 +54,54 @@ func main() {
 	// Initialize environment variables, database connection, and run migrations
 	initializers.Init()
 
+	// Initialize tracing before any spans are started (exporter selected by TRACING_EXPORTER)
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+
 	// Initialize cache client (Redis or no-op)
 	// Uses helper function from initializers to centralize cache creation logic
 	cacheClient := initializers.GetCacheClient()
 
 	// Create dependency injection container using Factory Pattern
 	// This initializes all repositories, services, and their dependencies
-	appContainer := container.NewContainer(initializers.DB, cacheClient)
+	appContainer := container.NewContainer(initializers.DB, cacheClient, initializers.ReplicaDBs...)
 
 	// Create a Gin router
 	router := gin.New()
 
-	// Add middleware: rate limiter, request logger, and recovery
+	// Start a server span per request, continuing the caller's trace from the traceparent header
+	cfg := config.Get()
+	router.Use(middleware.Tracing(cfg.Tracing.ServiceName))
+
+	// Tag every request with an X-Request-ID and a request-scoped logger (zerolog.Ctx) before anything can log or reject it
+	router.Use(middleware.RequestID())
+
+	// Resolve client IPs behind trusted proxies only, so clients cannot spoof X-Forwarded-For
+	clientIPResolver, err := middleware.NewClientIPResolver(config.Get())
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Invalid proxy configuration")
+	}
+	if err := clientIPResolver.Configure(router); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Invalid proxy configuration")
+	}
+	router.Use(clientIPResolver.Middleware())
+
+	// Record request count and latency per route; registered early so rejected requests are counted too
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics())
+		registerMetricsCollectors(cacheClient)
+	}
+
+	// Add middleware: rate limiter, request logger, replica routing, and recovery
+	// Global per-IP rate limit (health checks exempt); stricter policies are attached per route group
 	// Rate limiter uses Redis if available, otherwise falls back to in-memory
-	router.Use(middleware.RateLimitMiddlewareWithCache(cacheClient))
+	// IP filter runs first: denied and banned clients are rejected, allowlisted ones skip rate limits
+	router.Use(appContainer.IPFilter.Middleware())
+	router.Use(appContainer.RateLimiters.Middleware(middleware.RateLimitPolicyGlobal))
 	router.Use(middleware.RequestLogger())
+	router.Use(middleware.ReadYourWrites())
 	router.Use(gin.Recovery())
 
 	// Register all routes with dependency injection
@@ -71,6 +110,30 @@ func main() {
 	// Swagger documentation endpoint
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Auxiliary listeners (separate metrics port, debug server) run alongside the API server
+	var auxServers []auxServer
+
+	// Prometheus metrics: on the API port, or on a separate listener when METRICS_ADDR is set
+	if cfg.Metrics.Enabled {
+		if cfg.Metrics.Addr == "" {
+			if cfg.Metrics.Username == "" {
+				logger.Log.Warn().Msg("Metrics are served on the API port without authentication; set METRICS_ADDR to a private listener or METRICS_USERNAME and METRICS_PASSWORD")
+			}
+			routes.SetupMetricsRoutes(router)
+		} else {
+			auxServers = append(auxServers, newAuxServer("metrics", cfg.Metrics.Addr, routes.SetupMetricsRoutes))
+		}
+	}
+
+	// Admin/debug server (pprof, expvar, build info, config, log level) is never mounted on the public port
+	if cfg.Debug.Addr != "" {
+		if !isLoopbackAddr(cfg.Debug.Addr) && (cfg.Debug.Username == "" || cfg.Debug.Password == "") {
+			logger.Log.Error().Str("addr", cfg.Debug.Addr).Msg("Debug server not started: DEBUG_USERNAME and DEBUG_PASSWORD are required unless DEBUG_ADDR is a loopback address")
+		} else {
+			auxServers = append(auxServers, newAuxServer("debug", cfg.Debug.Addr, routes.SetupDebugRoutes))
+		}
+	}
+
 	// Start server on specified PORT or default to 8080
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -88,6 +151,9 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// Initialization is complete; the startup probe can pass
+	appContainer.Health.MarkStarted()
+
 	// Start server in a goroutine
 	go func() {
 		logger.Log.Info().Str("port", port).Msg("Server is running")
@@ -95,11 +161,26 @@ func main() {
 			logger.Log.Fatal().Err(err).Msg("Failed to start server")
 		}
 	}()
+	for _, aux := range auxServers {
+		go func() {
+			logger.Log.Info().Str("server", aux.name).Str("addr", aux.srv.Addr).Msg("Auxiliary server is running")
+			if err := aux.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Log.Fatal().Err(err).Str("server", aux.name).Msg("Failed to start auxiliary server")
+			}
+		}()
+	}
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	<-quit
 	logger.Log.Info().Msg("Shutting down server...")
 
+	// Fail readiness first and keep serving while load balancers stop routing new traffic here
+	appContainer.Health.MarkShuttingDown()
+	if delay := cfg.Health.ShutdownDrainDelay; delay > 0 {
+		logger.Log.Info().Dur("delay", delay).Msg("Draining traffic before shutdown")
+		time.Sleep(delay)
+	}
+
 	// Create context with timeout for graceful shutdown
 	// Give the server 30 seconds to finish handling existing requests
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -111,9 +192,70 @@ func main() {
 	} else {
 		logger.Log.Info().Msg("Server shutdown gracefully")
 	}
+	for _, aux := range auxServers {
+		if err := aux.srv.Shutdown(ctx); err != nil {
+			logger.Log.Error().Err(err).Str("server", aux.name).Msg("Auxiliary server forced to shutdown")
+		}
+	}
+
+	// Flush buffered spans to the exporter
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Log.Error().Err(err).Msg("Failed to flush traces")
+	}
 
 	// Cleanup: close Redis connection if it exists
 	initializers.CloseRedis()
 
 	logger.Log.Info().Msg("Server exited")
+	logger.Close()
+}
+
+// auxServer is an HTTP listener served next to the API server
+type auxServer struct {
+	name string
+	srv  *http.Server
+}
+
+// newAuxServer creates a listener on addr with its own Gin engine, set up by setup
+func newAuxServer(name, addr string, setup func(*gin.Engine)) auxServer {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	setup(engine)
+	return auxServer{name: name, srv: &http.Server{Addr: addr, Handler: engine}}
+}
+
+// isLoopbackAddr reports whether addr (host:port) only listens on the loopback interface
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// registerMetricsCollectors exports database pool and cache stats alongside the request metrics
+func registerMetricsCollectors(cacheClient cache.Cache) {
+	if sqlDB, err := initializers.DB.DB(); err == nil {
+		if err := metrics.RegisterDB("primary", sqlDB); err != nil {
+			logger.Log.Warn().Err(err).Msg("Failed to register database metrics")
+		}
+	}
+	for i, replica := range initializers.ReplicaDBs {
+		sqlDB, err := replica.DB()
+		if err != nil {
+			continue
+		}
+		if err := metrics.RegisterDB(fmt.Sprintf("replica-%d", i+1), sqlDB); err != nil {
+			logger.Log.Warn().Err(err).Msg("Failed to register replica metrics")
+		}
+	}
+	if stats := cache.StatsOf(cacheClient); stats != nil {
+		if err := metrics.RegisterCache(stats); err != nil {
+			logger.Log.Warn().Err(err).Msg("Failed to register cache metrics")
+		}
+	}
 }
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/leventeberry/goapi/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCollector exports a cache.Stats snapshot on every scrape
+type cacheCollector struct {
+	stats      *cache.Stats
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	errors     *prometheus.Desc
+	operations *prometheus.Desc
+	hitRatio   *prometheus.Desc
+}
+
+// newCacheCollector creates a collector for stats
+func newCacheCollector(stats *cache.Stats) *cacheCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "cache", name), help, []string{"family"}, nil)
+	}
+	return &cacheCollector{
+		stats:      stats,
+		hits:       desc("hits_total", "Cache lookups that found a value, by key family."),
+		misses:     desc("misses_total", "Cache lookups that found nothing, by key family."),
+		errors:     desc("errors_total", "Cache operations that failed, by key family."),
+		operations: desc("operations_total", "Cache operations, by key family."),
+		hitRatio:   desc("hit_ratio", "Share of cache lookups that found a value, by key family."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.errors
+	ch <- c.operations
+	ch <- c.hitRatio
+}
+
+// Collect implements prometheus.Collector
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, family := range c.stats.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(family.Hits), family.Family)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(family.Misses), family.Family)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(family.Errors), family.Family)
+		ch <- prometheus.MustNewConstMetric(c.operations, prometheus.CounterValue, float64(family.Operations), family.Family)
+		ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, family.HitRatio, family.Family)
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/leventeberry/goapi/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every application metric
+const Namespace = "goapi"
+
+// Login attempt results
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Registry holds every application metric
+// A dedicated registry (rather than the global default) keeps /metrics limited to what we register
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests by method, route template and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests handled, by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes request latency by method and route template
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// RateLimitRejections counts requests rejected by each rate limit policy
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by rate limiting, by policy.",
+	}, []string{"policy"})
+
+	// LoginAttempts counts login attempts by result (success or failure)
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "login_attempts_total",
+		Help:      "Login attempts, by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		RateLimitRejections,
+		LoginAttempts,
+	)
+}
+
+// RegisterDB exports connection pool stats (sql.DB.Stats) for a database, labeled db_name=role
+func RegisterDB(role string, db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, role))
+}
+
+// RegisterCache exports the cache counters collected by cache.Stats
+func RegisterCache(stats *cache.Stats) error {
+	return Registry.Register(newCacheCollector(stats))
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import "context"
+
+// Actor is the authenticated user making a request
+type Actor struct {
+	ID   int
+	Role string
+}
+
+// actorContextKey stores the Actor in the request context, for services that record who acted
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by AuthMiddleware; ok is false for anonymous requests
+func ActorFromContext(ctx context.Context) (actor Actor, ok bool) {
+	actor, ok = ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
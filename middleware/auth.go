@@ -37,7 +37,7 @@ func AuthMiddleware() gin.HandlerFunc {
     return func(c *gin.Context) {
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
+            AbortWithProblem(c, NewProblem(http.StatusUnauthorized, CodeUnauthorized, "Missing or invalid Authorization header"))
             return
         }
 
@@ -52,13 +52,13 @@ func AuthMiddleware() gin.HandlerFunc {
             return jwtSecret, nil
         })
         if err != nil {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+            AbortWithProblem(c, NewProblem(http.StatusUnauthorized, CodeInvalidToken, "Invalid or expired token"))
             return
         }
 
         claims, ok := token.Claims.(*Claims)
         if !ok || !token.Valid {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+            AbortWithProblem(c, NewProblem(http.StatusUnauthorized, CodeInvalidToken, "Invalid token claims"))
             return
         }
 
@@ -68,6 +68,11 @@ func AuthMiddleware() gin.HandlerFunc {
         c.Set("role", claims.Role)
         c.Set("expiresAt", claims.ExpiresAt.Time)
 
+        // Services see the caller through the request context (e.g. as the audit log actor)
+        if id, err := strconv.Atoi(claims.Subject); err == nil {
+            c.Request = c.Request.WithContext(WithActor(c.Request.Context(), Actor{ID: id, Role: claims.Role}))
+        }
+
         c.Next()
     }
 }
@@ -109,13 +114,13 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
         // Get role from context (set by AuthMiddleware from JWT claims)
         role, exists := c.Get("role")
         if !exists {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User role not found in context"})
+            AbortWithProblem(c, NewProblem(http.StatusUnauthorized, CodeUnauthorized, "User role not found in context"))
             return
         }
 
         roleStr, ok := role.(string)
         if !ok || roleStr == "" {
-            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid role format in token"})
+            AbortWithProblem(c, NewProblem(http.StatusInternalServerError, CodeInternal, "Invalid role format in token"))
             return
         }
 
@@ -129,7 +134,7 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
         }
 
         if !hasRole {
-            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+            AbortWithProblem(c, NewProblem(http.StatusForbidden, CodeForbidden, "Insufficient permissions"))
             return
         }
 
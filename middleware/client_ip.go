@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/config"
+)
+
+// DefaultIPv6PrefixLength groups IPv6 clients by /64, the block a single subscriber usually receives
+const DefaultIPv6PrefixLength = 64
+
+// Context keys set by ClientIPResolver.Middleware
+const (
+	clientIPKey      = "clientIP"
+	clientIPGroupKey = "clientIPGroup"
+)
+
+// clientIPContextKey stores the client IP in the request context, for code without a *gin.Context
+type clientIPContextKey struct{}
+
+// forwardedHeader is the RFC 7239 header, parsed here because gin only understands X-Forwarded-For style lists
+const forwardedHeader = "Forwarded"
+
+// platformHeaders maps TRUSTED_PLATFORM names to the header the platform sets
+var platformHeaders = map[string]string{
+	"cloudflare":        gin.PlatformCloudflare,
+	"google-app-engine": gin.PlatformGoogleAppEngine,
+	"fly":               gin.PlatformFlyIO,
+}
+
+// ClientIPResolver determines the real client IP behind trusted proxies
+// Forwarding headers from untrusted peers are ignored so clients cannot spoof their address
+type ClientIPResolver struct {
+	trusted          []netip.Prefix
+	headers          []string // X-Forwarded-For style headers, handed to gin
+	useForwarded     bool
+	platform         string
+	ipv6PrefixLength int
+}
+
+// NewClientIPResolver creates a resolver from the proxy configuration
+func NewClientIPResolver(cfg *config.Config) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{ipv6PrefixLength: cfg.Proxy.IPv6PrefixLength}
+
+	for _, proxy := range cfg.Proxy.TrustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+
+	for _, header := range cfg.Proxy.ClientIPHeaders {
+		if strings.EqualFold(header, forwardedHeader) {
+			r.useForwarded = true
+		} else {
+			r.headers = append(r.headers, header)
+		}
+	}
+
+	if platform := cfg.Proxy.TrustedPlatform; platform != "" {
+		if header, ok := platformHeaders[strings.ToLower(platform)]; ok {
+			r.platform = header
+		} else {
+			r.platform = platform
+		}
+	}
+	return r, nil
+}
+
+// parsePrefix accepts a CIDR or a single IP
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// Configure applies the trusted proxies, headers and platform to the Gin engine so c.ClientIP agrees
+func (r *ClientIPResolver) Configure(engine *gin.Engine) error {
+	proxies := make([]string, len(r.trusted))
+	for i, prefix := range r.trusted {
+		proxies[i] = prefix.String()
+	}
+	// nil trusts nobody; Gin's default is to trust every proxy
+	if len(proxies) == 0 {
+		proxies = nil
+	}
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	engine.RemoteIPHeaders = r.headers
+	engine.TrustedPlatform = r.platform
+	return nil
+}
+
+// Middleware resolves the client IP once per request and stores it (and its rate limit group) in the context
+// Must run before middleware that reads ClientIP, such as rate limiting and request logging
+func (r *ClientIPResolver) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := r.resolve(c)
+		c.Set(clientIPKey, ip)
+		c.Set(clientIPGroupKey, groupIP(ip, r.ipv6PrefixLength))
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), clientIPContextKey{}, ip))
+		c.Next()
+	}
+}
+
+// resolve returns the client IP for a request
+// A trusted platform header wins, then the RFC 7239 Forwarded header, then Gin's X-Forwarded-For handling
+func (r *ClientIPResolver) resolve(c *gin.Context) string {
+	if r.platform == "" && r.useForwarded {
+		if remote, ok := r.remoteAddr(c); ok && r.isTrusted(remote) {
+			if ip, ok := r.fromForwarded(c.Request.Header.Values(forwardedHeader)); ok {
+				return ip.String()
+			}
+		}
+	}
+	return c.ClientIP()
+}
+
+// remoteAddr returns the address of the direct peer
+func (r *ClientIPResolver) remoteAddr(c *gin.Context) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		host = c.Request.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return addr.Unmap(), err == nil
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy
+func (r *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromForwarded walks the Forwarded hops from the nearest proxy back, returning the first untrusted address
+// Stops at obfuscated or unknown identifiers since nothing before them can be verified
+func (r *ClientIPResolver) fromForwarded(values []string) (netip.Addr, bool) {
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := forwardedFor(hops[i])
+		if !ok {
+			return netip.Addr{}, false
+		}
+		if i == 0 || !r.isTrusted(addr) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// forwardedFor extracts the for= address of one Forwarded element,
+// e.g. `for=192.0.2.60;proto=https` or `for="[2001:db8::1]:4711"`
+func forwardedFor(element string) (netip.Addr, bool) {
+	for _, pair := range strings.Split(element, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(name, "for") {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if strings.HasPrefix(value, "[") {
+			// [IPv6]:port or [IPv6]
+			if end := strings.IndexByte(value, ']'); end > 0 {
+				value = value[1:end]
+			}
+		} else if host, _, err := net.SplitHostPort(value); err == nil {
+			value = host
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// groupIP returns the rate limit identity of an IP
+// IPv4 addresses are used as-is (IPv4-mapped IPv6 addresses count as IPv4); IPv6 addresses are
+// reduced to their prefix so one subscriber cannot dodge limits by rotating through the addresses of its block
+func groupIP(ip string, ipv6PrefixLength int) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	if addr = addr.Unmap(); addr.Is4() {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(ipv6PrefixLength)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+// ClientIP returns the client IP resolved by ClientIPResolver.Middleware, or c.ClientIP() without it
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(clientIPKey); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// ClientIPFromContext returns the client IP stored in a request context by ClientIPResolver.Middleware
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPContextKey{}).(string)
+	return ip
+}
+
+// ClientIPGroup returns the rate limit identity of the client IP (IPv6 grouped by prefix)
+func ClientIPGroup(c *gin.Context) string {
+	if group := c.GetString(clientIPGroupKey); group != "" {
+		return group
+	}
+	return groupIP(c.ClientIP(), DefaultIPv6PrefixLength)
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/config"
+)
+
+// proxyConfig returns a config trusting proxies with the default client IP headers
+func proxyConfig(trusted ...string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Proxy.TrustedProxies = trusted
+	cfg.Proxy.ClientIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+	cfg.Proxy.IPv6PrefixLength = DefaultIPv6PrefixLength
+	return cfg
+}
+
+// resolveClientIP runs one request through the resolver and returns the client IP and its group
+func resolveClientIP(t *testing.T, cfg *config.Config, remoteAddr string, headers http.Header) (ip, group string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	resolver, err := NewClientIPResolver(cfg)
+	if err != nil {
+		t.Fatalf("NewClientIPResolver: %v", err)
+	}
+	engine := gin.New()
+	if err := resolver.Configure(engine); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	engine.Use(resolver.Middleware())
+	engine.GET("/", func(c *gin.Context) {
+		ip, group = ClientIP(c), ClientIPGroup(c)
+		if fromCtx := ClientIPFromContext(c.Request.Context()); fromCtx != ip {
+			t.Errorf("ClientIPFromContext = %q, ClientIP = %q", fromCtx, ip)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	return ip, group
+}
+
+func TestClientIPResolver(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *config.Config
+		remoteAddr string
+		headers    http.Header
+		want       string
+	}{
+		{
+			name:       "no headers",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "203.0.113.7:5000",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "X-Forwarded-For from untrusted peer is ignored",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "203.0.113.7:5000",
+			headers:    http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded from untrusted peer is ignored",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "203.0.113.7:5000",
+			headers:    http.Header{"Forwarded": {"for=198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "X-Real-IP from untrusted peer is ignored",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "203.0.113.7:5000",
+			headers:    http.Header{"X-Real-Ip": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "headers are ignored when no proxy is trusted",
+			cfg:        proxyConfig(),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=198.51.100.1"}, "X-Forwarded-For": {"198.51.100.1"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "X-Forwarded-For from trusted peer",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"X-Forwarded-For": {"203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "X-Forwarded-For trusted hop chain stops at first untrusted hop",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7, 10.0.0.2"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded trusted hop chain stops at first untrusted hop",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=198.51.100.1, for=203.0.113.7;proto=https, for=10.0.0.2"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded hops across header lines",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=203.0.113.7", "for=10.0.0.2;by=10.0.0.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded wins over X-Forwarded-For",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=203.0.113.7"}, "X-Forwarded-For": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded chain of only trusted hops returns the first hop",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=10.0.0.3, for=10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "Forwarded quoted IPv4 with port",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {`for="203.0.113.7:8080"`}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forwarded quoted IPv6 with port",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}},
+			want:       "2001:db8:cafe::17",
+		},
+		{
+			name:       "obfuscated for= stops the walk",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=198.51.100.1, for=_hidden, for=10.0.0.2"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "unknown for= stops the walk",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=unknown"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "obfuscated for= falls back to X-Forwarded-For",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"for=_hidden"}, "X-Forwarded-For": {"203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "element without for= is skipped",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {"proto=https;by=10.0.0.1"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "IPv4-mapped peer matches IPv4 trusted range",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "[::ffff:10.0.0.1]:5000",
+			headers:    http.Header{"Forwarded": {"for=203.0.113.7"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IPv4-mapped for= is unmapped",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {`for="[::ffff:203.0.113.7]"`}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IPv4-mapped trusted hop is skipped",
+			cfg:        proxyConfig("10.0.0.0/8"),
+			remoteAddr: "10.0.0.1:5000",
+			headers:    http.Header{"Forwarded": {`for=203.0.113.7, for="[::ffff:10.0.0.2]"`}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IPv6 trusted proxy",
+			cfg:        proxyConfig("2001:db8:ffff::/48"),
+			remoteAddr: "[2001:db8:ffff::1]:5000",
+			headers:    http.Header{"Forwarded": {`for="[2001:db8:1::7]"`}},
+			want:       "2001:db8:1::7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := resolveClientIP(t, tt.cfg, tt.remoteAddr, tt.headers); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPResolverTrustedPlatform(t *testing.T) {
+	cfg := proxyConfig("10.0.0.0/8")
+	cfg.Proxy.TrustedPlatform = "cloudflare"
+	headers := http.Header{"Cf-Connecting-Ip": {"203.0.113.7"}, "Forwarded": {"for=198.51.100.1"}}
+	if got, _ := resolveClientIP(t, cfg, "10.0.0.1:5000", headers); got != "203.0.113.7" {
+		t.Errorf("client IP = %q, want the platform header", got)
+	}
+}
+
+func TestClientIPGroup(t *testing.T) {
+	tests := []struct {
+		name         string
+		ip           string
+		prefixLength int
+		want         string
+	}{
+		{"IPv4 as is", "203.0.113.7", 64, "203.0.113.7"},
+		{"IPv6 grouped by /64", "2001:db8:1:2:aaaa:bbbb:cccc:dddd", 64, "2001:db8:1:2::/64"},
+		{"IPv6 addresses of one /64 share a group", "2001:db8:1:2::1", 64, "2001:db8:1:2::/64"},
+		{"IPv6 grouped by /48", "2001:db8:1:2::1", 48, "2001:db8:1::/48"},
+		{"IPv6 /128 keeps the address", "2001:db8::1", 128, "2001:db8::1/128"},
+		{"IPv4-mapped grouped as IPv4", "::ffff:203.0.113.7", 64, "203.0.113.7"},
+		{"invalid input as is", "not-an-ip", 64, "not-an-ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupIP(tt.ip, tt.prefixLength); got != tt.want {
+				t.Errorf("groupIP(%q, %d) = %q, want %q", tt.ip, tt.prefixLength, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPGroupThroughMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"IPv4 peer", "203.0.113.7:5000", "203.0.113.7"},
+		{"IPv6 peer grouped by /64", "[2001:db8:1:2::99]:5000", "2001:db8:1:2::/64"},
+		{"IPv4-mapped peer", "[::ffff:203.0.113.7]:5000", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := resolveClientIP(t, proxyConfig(), tt.remoteAddr, nil); got != tt.want {
+				t.Errorf("client IP group = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8", false},
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"10.0.0.1", "10.0.0.1/32", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"::ffff:10.0.0.1", "10.0.0.1/32", false},
+		{"10.0.0.0/33", "", true},
+		{"proxy.internal", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePrefix(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePrefix(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != netip.MustParsePrefix(tt.want) {
+				t.Errorf("parsePrefix(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolverRejectsInvalidProxy(t *testing.T) {
+	if _, err := NewClientIPResolver(proxyConfig("10.0.0.0/8", "not-a-proxy")); err == nil {
+		t.Fatal("NewClientIPResolver accepted an invalid trusted proxy")
+	}
+}
@@ -0,0 +1,381 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/logger"
+	"github.com/rs/zerolog"
+)
+
+// IP rule actions
+const (
+	IPRuleAllow = "allow"
+	IPRuleDeny  = "deny"
+)
+
+// IP rule sources
+const (
+	IPRuleSourceConfig = "config"
+	IPRuleSourceAdmin  = "admin"
+)
+
+// banStrikeHistory is how long previous bans count towards escalation
+const banStrikeHistory = 7 * 24 * time.Hour
+
+// ipRulesUpdateAttempts bounds the retries of an admin rule change that keeps losing to concurrent writers
+const ipRulesUpdateAttempts = 100
+
+// errIPRulesContended is returned when an admin rule change keeps losing to concurrent writers
+var errIPRulesContended = errors.New("IP rules are being changed concurrently, try again")
+
+// IPRule allows or denies an IP range
+type IPRule struct {
+	CIDR      string    `json:"cidr"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	prefix    netip.Prefix
+}
+
+// IPBan is a temporary ban issued for repeated rate limit violations
+type IPBan struct {
+	IP      string    `json:"ip"`
+	Strikes int       `json:"strikes"` // bans issued within the escalation history, including this one
+	Until   time.Time `json:"until"`
+}
+
+// IPFilter rejects denied and banned clients and exempts allowlisted ones from rate limiting
+// Config rules are fixed at startup; admin rules and bans live in cache.Cache so every instance shares them
+type IPFilter struct {
+	cache          cache.Cache
+	persistent     bool // false when the cache drops writes, leaving only the config rules
+	configRules    []IPRule
+	banThreshold   int
+	banWindow      time.Duration
+	banDuration    time.Duration
+	banMaxDuration time.Duration
+	ipv6Prefix     int
+
+	// Snapshot of config + admin rules, reloaded from the cache every refresh interval
+	mu         sync.RWMutex
+	rules      []IPRule
+	loadedAt   time.Time
+	refresh    time.Duration
+	refreshing atomic.Bool
+}
+
+// NewIPFilter creates an IP filter from the IP_* settings
+func NewIPFilter(cacheClient cache.Cache, cfg *config.Config) *IPFilter {
+	f := &IPFilter{
+		cache:          cacheClient,
+		persistent:     cacheUsable(cacheClient),
+		banThreshold:   cfg.IPFilter.BanThreshold,
+		banWindow:      cfg.IPFilter.BanWindow,
+		banDuration:    cfg.IPFilter.BanDuration,
+		banMaxDuration: cfg.IPFilter.BanMaxDuration,
+		ipv6Prefix:     cfg.Proxy.IPv6PrefixLength,
+		refresh:        cfg.IPFilter.RulesRefresh,
+	}
+	for _, cidr := range cfg.IPFilter.Allow {
+		if rule, err := NewIPRule(cidr, IPRuleAllow, ""); err == nil {
+			rule.Source = IPRuleSourceConfig
+			f.configRules = append(f.configRules, *rule)
+		}
+	}
+	for _, cidr := range cfg.IPFilter.Deny {
+		if rule, err := NewIPRule(cidr, IPRuleDeny, ""); err == nil {
+			rule.Source = IPRuleSourceConfig
+			f.configRules = append(f.configRules, *rule)
+		}
+	}
+	f.rules = f.configRules
+	if !f.persistent {
+		logger.Log.Warn().Msg("Cache backend does not store values: admin IP rules and automatic bans are disabled (set CACHE_BACKEND to redis, tiered or memory)")
+	}
+	return f
+}
+
+// Persistent reports whether admin rules and bans can be stored
+// False with the no-op cache (CACHE_BACKEND=none or Redis unreachable at startup)
+func (f *IPFilter) Persistent() bool {
+	return f.persistent
+}
+
+// NewIPRule validates and normalizes a rule; single IPs become /32 or /128
+func NewIPRule(cidr, action, note string) (*IPRule, error) {
+	if action != IPRuleAllow && action != IPRuleDeny {
+		return nil, errors.New("action must be allow or deny")
+	}
+	prefix, err := parsePrefix(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return &IPRule{CIDR: prefix.String(), Action: action, Note: note, prefix: prefix}, nil
+}
+
+// Middleware enforces the rules and bans
+// Allowlisted clients skip bans and every rate limit policy, so attach it before the rate limiters
+func (f *IPFilter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, err := netip.ParseAddr(ClientIP(c))
+		if err != nil {
+			c.Next()
+			return
+		}
+		addr = addr.Unmap()
+
+		switch f.match(c.Request.Context(), addr) {
+		case IPRuleAllow:
+			c.Set(rateLimitExemptKey, true)
+			c.Next()
+			return
+		case IPRuleDeny:
+			AbortWithProblem(c, NewProblem(http.StatusForbidden, CodeIPDenied, "Access denied"))
+			return
+		}
+
+		ban, err := f.Ban(c.Request.Context(), ClientIPGroup(c))
+		if err != nil {
+			// Fail open: a cache outage must not lock everyone out
+			zerolog.Ctx(c.Request.Context()).Warn().Err(err).Msg("Failed to check IP ban")
+		} else if ban != nil {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(time.Until(ban.Until), 1)))
+			AbortWithProblem(c, NewProblem(http.StatusForbidden, CodeIPBanned, "Temporarily banned after repeated rate limit violations"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// match returns the action of the rules matching addr; allow rules win over deny rules
+func (f *IPFilter) match(ctx context.Context, addr netip.Addr) string {
+	action := ""
+	for _, rule := range f.snapshot(ctx) {
+		if rule.prefix.Contains(addr) {
+			if rule.Action == IPRuleAllow {
+				return IPRuleAllow
+			}
+			action = IPRuleDeny
+		}
+	}
+	return action
+}
+
+// snapshot returns the current rules, reloading admin rules when the snapshot is stale
+// Only one request reloads at a time; the others keep using the previous snapshot
+func (f *IPFilter) snapshot(ctx context.Context) []IPRule {
+	f.mu.RLock()
+	rules, stale := f.rules, time.Since(f.loadedAt) >= f.refresh
+	f.mu.RUnlock()
+
+	if stale && f.refreshing.CompareAndSwap(false, true) {
+		defer f.refreshing.Store(false)
+		if err := f.reload(ctx); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to reload IP rules")
+		}
+		f.mu.RLock()
+		rules = f.rules
+		f.mu.RUnlock()
+	}
+	return rules
+}
+
+// reload merges the admin rules stored in the cache with the config rules
+func (f *IPFilter) reload(ctx context.Context) error {
+	adminRules, err := f.adminRules(ctx)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(slices.Clip(f.configRules), adminRules...)
+	f.loadedAt = time.Now()
+	return nil
+}
+
+// adminRules loads the admin-managed rules from the cache
+func (f *IPFilter) adminRules(ctx context.Context) ([]IPRule, error) {
+	rules, _, err := f.loadAdminRules(ctx)
+	return rules, err
+}
+
+// loadAdminRules returns the admin-managed rules and their stored JSON ("" if none are stored)
+func (f *IPFilter) loadAdminRules(ctx context.Context) ([]IPRule, string, error) {
+	data, err := f.cache.Get(ctx, cache.IPRulesKey)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	var stored []IPRule
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, "", err
+	}
+	rules := make([]IPRule, 0, len(stored))
+	for _, rule := range stored {
+		if rule.prefix, err = parsePrefix(rule.CIDR); err == nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, data, nil
+}
+
+// updateAdminRules applies change to the admin-managed rules and refreshes this instance's snapshot
+// The write only lands if no other admin or instance changed the rules since they were read; otherwise change is retried on the new rules
+// change returns false to leave the rules untouched
+// Other instances pick the change up within the refresh interval
+func (f *IPFilter) updateAdminRules(ctx context.Context, change func(rules []IPRule) ([]IPRule, bool)) (bool, error) {
+	for attempt := 0; attempt < ipRulesUpdateAttempts; attempt++ {
+		rules, old, err := f.loadAdminRules(ctx)
+		if err != nil {
+			return false, err
+		}
+		updated, changed := change(rules)
+		if !changed {
+			return false, nil
+		}
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return false, err
+		}
+		swapped, err := f.cache.CompareAndSwap(ctx, cache.IPRulesKey, old, string(data))
+		if err != nil {
+			return false, err
+		}
+		if swapped {
+			return true, f.reload(ctx)
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+	}
+	return false, errIPRulesContended
+}
+
+// Rules returns the config rules followed by the admin rules
+func (f *IPFilter) Rules(ctx context.Context) ([]IPRule, error) {
+	adminRules, err := f.adminRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(slices.Clip(f.configRules), adminRules...), nil
+}
+
+// AddRule stores an admin rule; returns false if the same CIDR and action already exist
+func (f *IPFilter) AddRule(ctx context.Context, rule IPRule) (bool, error) {
+	rule.Source = IPRuleSourceAdmin
+	rule.CreatedAt = time.Now().UTC()
+	return f.updateAdminRules(ctx, func(rules []IPRule) ([]IPRule, bool) {
+		for _, existing := range rules {
+			if existing.CIDR == rule.CIDR && existing.Action == rule.Action {
+				return nil, false
+			}
+		}
+		return append(rules, rule), true
+	})
+}
+
+// RemoveRule deletes an admin rule; returns false if it does not exist
+// Config rules cannot be removed at runtime
+func (f *IPFilter) RemoveRule(ctx context.Context, cidr, action string) (bool, error) {
+	return f.updateAdminRules(ctx, func(rules []IPRule) ([]IPRule, bool) {
+		kept := slices.DeleteFunc(rules, func(rule IPRule) bool {
+			return rule.CIDR == cidr && rule.Action == action
+		})
+		return kept, len(kept) != len(rules)
+	})
+}
+
+// BanKey returns the identity bans are tracked by (IPv6 grouped by prefix)
+func (f *IPFilter) BanKey(ip string) string {
+	return groupIP(ip, f.ipv6Prefix)
+}
+
+// Ban returns the active ban for an IP group, or nil if there is none
+func (f *IPFilter) Ban(ctx context.Context, ipGroup string) (*IPBan, error) {
+	data, err := f.cache.Get(ctx, cache.IPBanKeyPrefix+ipGroup)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ban IPBan
+	if err := json.Unmarshal([]byte(data), &ban); err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(ban.Until) {
+		return nil, nil
+	}
+	return &ban, nil
+}
+
+// LiftBan removes an active ban; the strike history still counts towards the next ban
+func (f *IPFilter) LiftBan(ctx context.Context, ipGroup string) error {
+	return f.cache.Delete(ctx, cache.IPBanKeyPrefix+ipGroup)
+}
+
+// RecordViolation counts a rate limit rejection and bans the client once it crosses the threshold
+// Each ban within the strike history doubles the previous duration, up to the maximum
+// Only rejections keyed by IP count: one noisy account must not get everyone behind a shared NAT banned
+// Registered with RateLimiters.OnReject
+func (f *IPFilter) RecordViolation(c *gin.Context, policy, key string) {
+	if !f.persistent || f.banThreshold == 0 || f.banDuration == 0 || !strings.HasPrefix(key, ipKeyPrefix) {
+		return
+	}
+	ctx := c.Request.Context()
+	ipGroup := ClientIPGroup(c)
+
+	violations, err := f.cache.IncrementRateLimit(ctx, "violations:"+ipGroup, f.banWindow)
+	if err != nil || violations < f.banThreshold {
+		return
+	}
+
+	strikes, err := f.cache.IncrementRateLimit(ctx, "strikes:"+ipGroup, banStrikeHistory)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("ip", ipGroup).Msg("Failed to record IP ban strike")
+		return
+	}
+	duration := f.banDuration
+	for i := 1; i < strikes && duration < f.banMaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > f.banMaxDuration {
+		duration = f.banMaxDuration
+	}
+
+	ban := IPBan{IP: ipGroup, Strikes: strikes, Until: time.Now().Add(duration).UTC()}
+	data, err := json.Marshal(ban)
+	if err == nil {
+		err = f.cache.Set(ctx, cache.IPBanKeyPrefix+ipGroup, string(data), duration)
+	}
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("ip", ipGroup).Msg("Failed to ban IP")
+		return
+	}
+	f.cache.ResetRateLimit(ctx, "violations:"+ipGroup)
+
+	zerolog.Ctx(ctx).Warn().
+		Str("ip", ipGroup).
+		Str("policy", policy).
+		Int("strikes", strikes).
+		Dur("duration", duration).
+		Msg("Banned IP after repeated rate limit violations")
+}
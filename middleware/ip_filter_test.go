@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
+)
+
+// ipFilterConfig returns a config banning after two violations
+func ipFilterConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Proxy.IPv6PrefixLength = DefaultIPv6PrefixLength
+	cfg.IPFilter.BanThreshold = 2
+	cfg.IPFilter.BanWindow = time.Minute
+	cfg.IPFilter.BanDuration = time.Minute
+	cfg.IPFilter.BanMaxDuration = time.Hour
+	cfg.IPFilter.RulesRefresh = time.Minute
+	return cfg
+}
+
+func TestIPFilterPersistence(t *testing.T) {
+	tests := []struct {
+		name           string
+		cache          cache.Cache
+		wantPersistent bool
+	}{
+		{"no-op cache", cache.NewNoOpCache(), false},
+		{"nil cache", nil, false},
+		{"memory cache", cache.NewMemoryCache(10), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewIPFilter(tt.cache, ipFilterConfig()).Persistent(); got != tt.wantPersistent {
+				t.Errorf("Persistent() = %v, want %v", got, tt.wantPersistent)
+			}
+		})
+	}
+}
+
+func TestIPFilterAdminRulesApply(t *testing.T) {
+	ctx := context.Background()
+	// A single-entry cache would evict the rules without the protected key exemption
+	filter := NewIPFilter(cache.NewMemoryCache(1), ipFilterConfig())
+
+	rule, err := NewIPRule("203.0.113.0/24", IPRuleDeny, "test")
+	if err != nil {
+		t.Fatalf("NewIPRule: %v", err)
+	}
+	if added, err := filter.AddRule(ctx, *rule); err != nil || !added {
+		t.Fatalf("AddRule = %v, %v", added, err)
+	}
+	filter.cache.Set(ctx, "unrelated", "value", 0)
+	filter.cache.Set(ctx, "another", "value", 0)
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.7", IPRuleDeny},
+		{"198.51.100.1", ""},
+	}
+	for _, tt := range tests {
+		if got := filter.match(ctx, netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("match(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+
+	rules, err := filter.Rules(ctx)
+	if err != nil || len(rules) != 1 || rules[0].Source != IPRuleSourceAdmin {
+		t.Errorf("Rules() = %+v, %v, want the admin rule", rules, err)
+	}
+}
+
+// slowReadCache delays every read so concurrent read-modify-write cycles overlap
+type slowReadCache struct {
+	cache.Cache
+}
+
+func (c slowReadCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.Cache.Get(ctx, key)
+	time.Sleep(time.Millisecond)
+	return value, err
+}
+
+func TestIPFilterConcurrentRuleEdits(t *testing.T) {
+	ctx := context.Background()
+	shared := slowReadCache{cache.NewMemoryCache(10)}
+	// Two instances sharing one cache, like two API servers sharing Redis
+	instances := []*IPFilter{NewIPFilter(shared, ipFilterConfig()), NewIPFilter(shared, ipFilterConfig())}
+
+	const edits = 32
+	keep := make([]IPRule, edits)
+	for i := range keep {
+		rule, err := NewIPRule(netip.AddrFrom4([4]byte{198, 51, 100, byte(i)}).String(), IPRuleDeny, "")
+		if err != nil {
+			t.Fatalf("NewIPRule: %v", err)
+		}
+		keep[i] = *rule
+	}
+	removed, _ := NewIPRule("203.0.113.0/24", IPRuleDeny, "")
+	if _, err := instances[0].AddRule(ctx, *removed); err != nil {
+		t.Fatalf("AddRule: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, edits+1)
+	for i, rule := range keep {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := instances[i%2].AddRule(ctx, rule); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if ok, err := instances[1].RemoveRule(ctx, removed.CIDR, removed.Action); err != nil || !ok {
+			errs <- fmt.Errorf("RemoveRule = %v, %v", ok, err)
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	rules, err := instances[0].Rules(ctx)
+	if err != nil {
+		t.Fatalf("Rules: %v", err)
+	}
+	stored := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		stored[rule.CIDR] = true
+	}
+	for _, rule := range keep {
+		if !stored[rule.CIDR] {
+			t.Errorf("concurrently added rule %s was lost", rule.CIDR)
+		}
+	}
+	if stored[removed.CIDR] {
+		t.Errorf("concurrently removed rule %s is still stored", removed.CIDR)
+	}
+	if len(rules) != edits {
+		t.Errorf("stored %d rules, want %d", len(rules), edits)
+	}
+}
+
+func TestIPFilterBansAfterViolations(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   cache.Cache
+		policy  string
+		key     string
+		wantBan bool
+	}{
+		{"memory cache bans", cache.NewMemoryCache(10), RateLimitPolicyGlobal, "ip:203.0.113.7", true},
+		{"auth policy bans", cache.NewMemoryCache(10), RateLimitPolicyAuth, "ip:203.0.113.7", true},
+		{"anonymous user policy bans the IP", cache.NewMemoryCache(10), RateLimitPolicyUser, "ip:203.0.113.7", true},
+		{"user-keyed rejections never ban the IP", cache.NewMemoryCache(10), RateLimitPolicyUser, "user:42", false},
+		{"no-op cache never bans", cache.NewNoOpCache(), RateLimitPolicyGlobal, "ip:203.0.113.7", false},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewIPFilter(tt.cache, ipFilterConfig())
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = "203.0.113.7:5000"
+
+			for i := 0; i < 2; i++ {
+				filter.RecordViolation(c, tt.policy, tt.key)
+			}
+			ban, err := filter.Ban(c.Request.Context(), "203.0.113.7")
+			if err != nil {
+				t.Fatalf("Ban: %v", err)
+			}
+			if (ban != nil) != tt.wantBan {
+				t.Errorf("ban = %+v, want banned=%v", ban, tt.wantBan)
+			}
+		})
+	}
+}
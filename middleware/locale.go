@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/i18n"
+)
+
+// localeKey caches the negotiated locale in the Gin context
+const localeKey = "locale"
+
+// Locale returns the response language negotiated from the Accept-Language header (English by default)
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(localeKey); locale != "" {
+		return locale
+	}
+	locale := i18n.Match(c.GetHeader("Accept-Language"))
+	c.Set(localeKey, locale)
+	return locale
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/i18n"
+)
+
+func TestLocaleNegotiation(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantLocale     string
+	}{
+		{"English", "en-GB,en;q=0.9", i18n.English},
+		{"French", "fr-FR,fr;q=0.9,en;q=0.5", i18n.French},
+		{"German", "de-AT", i18n.German},
+		{"unsupported language falls back to English", "ja-JP", i18n.English},
+		{"no header falls back to English", "", i18n.English},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				AbortWithProblem(c, NewProblem(http.StatusForbidden, CodeForbidden, "Insufficient permissions"))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Language"); got != tt.wantLocale {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLocale)
+			}
+			if vary := w.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Accept-Language") {
+				t.Errorf("Vary = %v, want Accept-Language", vary)
+			}
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			wantTitle := i18n.Message(tt.wantLocale, "Forbidden")
+			wantDetail := i18n.Message(tt.wantLocale, "Insufficient permissions")
+			if problem.Title != wantTitle || problem.Detail != wantDetail {
+				t.Errorf("title, detail = %q, %q, want %q, %q", problem.Title, problem.Detail, wantTitle, wantDetail)
+			}
+			if tt.wantLocale != i18n.English && problem.Detail == "Insufficient permissions" {
+				t.Errorf("detail was not translated into %s", tt.wantLocale)
+			}
+			if problem.Code != CodeForbidden {
+				t.Errorf("code = %q, want it untranslated", problem.Code)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/leventeberry/goapi/logger"
+	"github.com/rs/zerolog"
 )
 
 // RequestLogger returns a middleware that logs HTTP requests with details.
@@ -23,7 +23,7 @@ func RequestLogger() gin.HandlerFunc {
 		latency := time.Since(start)
 
 		// Get client IP
-		clientIP := c.ClientIP()
+		clientIP := ClientIP(c)
 
 		// Get user agent
 		userAgent := c.Request.UserAgent()
@@ -41,7 +41,8 @@ func RequestLogger() gin.HandlerFunc {
 		}
 
 		// Create structured log event with common fields
-		baseEvent := logger.Log.
+		// The request-scoped logger already carries request_id and trace_id
+		baseEvent := zerolog.Ctx(c.Request.Context()).
 			With().
 			Str("method", method).
 			Str("path", fullPath).
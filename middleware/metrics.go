@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/metrics"
+)
+
+// unmatchedRoute labels requests that matched no route, so random paths can't explode label cardinality
+const unmatchedRoute = "unmatched"
+
+// Metrics returns a middleware that records request counts and latency per route template
+// Labels use c.FullPath() (e.g. /api/v1/users/:id), never the raw path
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
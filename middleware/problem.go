@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/i18n"
+	"github.com/rs/zerolog"
+)
+
+// ProblemContentType is the RFC 7807 media type of every error response
+const ProblemContentType = "application/problem+json"
+
+// Stable error codes for problems raised outside the services
+// Clients should branch on codes, never on the human-readable detail
+const (
+	CodeValidationFailed = "validation_failed"
+	CodeMalformedRequest = "malformed_request"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnauthorized     = "unauthorized"
+	CodeInvalidToken     = "invalid_token"
+	CodeForbidden        = "forbidden"
+	CodeIPDenied         = "ip_denied"
+	CodeIPBanned         = "ip_banned"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details error response
+// It implements error so handlers can pass it to c.Error like any other error
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"` // Per-field validation failures
+}
+
+// FieldError describes why one request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewProblem creates a problem; the title is the standard text for status
+// The type is about:blank because code, not type, identifies the problem
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// ValidationProblem creates a 400 problem listing the rejected fields
+func ValidationProblem(fieldErrors ...FieldError) *Problem {
+	problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "Request validation failed")
+	problem.Errors = fieldErrors
+	return problem
+}
+
+// Error implements error
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Code, p.Detail)
+}
+
+// AbortWithProblem writes problem as application/problem+json and stops the handler chain
+// The request ID and path are filled in so clients can quote them in reports,
+// and the title, detail and field messages are translated for the request's Accept-Language
+// Codes are never translated
+func AbortWithProblem(c *gin.Context, problem *Problem) {
+	locale := Locale(c)
+	response := *problem
+	response.Title = i18n.Message(locale, problem.Title)
+	response.Detail = i18n.Message(locale, problem.Detail)
+	response.RequestID = GetRequestID(c)
+	response.Instance = c.Request.URL.Path
+	if len(problem.Errors) > 0 {
+		response.Errors = make([]FieldError, len(problem.Errors))
+		for i, fieldErr := range problem.Errors {
+			fieldErr.Message = i18n.Message(locale, fieldErr.Message)
+			response.Errors[i] = fieldErr
+		}
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", locale)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	c.AbortWithStatusJSON(response.Status, response)
+}
+
+// ProblemMapper converts an error returned by a service to a problem; nil means the error is unexpected
+type ProblemMapper func(err error) *Problem
+
+// ErrorHandler returns a middleware that renders the last error added with c.Error as a problem
+// Handlers report failures with c.Error(err) and return; errors are matched in this order:
+// a *Problem as is, binding errors as validation problems, then mapper; anything else is a 500
+func ErrorHandler(mapper ProblemMapper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		AbortWithProblem(c, problemFor(c, c.Errors.Last(), mapper))
+	}
+}
+
+// problemFor picks the problem describing err
+func problemFor(c *gin.Context, err *gin.Error, mapper ProblemMapper) *Problem {
+	var problem *Problem
+	if errors.As(err.Err, &problem) {
+		return problem
+	}
+	if err.IsType(gin.ErrorTypeBind) {
+		return bindingProblem(err.Err, Locale(c))
+	}
+	if problem := mapper(err.Err); problem != nil {
+		return problem
+	}
+
+	// The cause stays in the log; clients only get the request ID to quote
+	zerolog.Ctx(c.Request.Context()).Error().Err(err.Err).Msg("Unhandled request error")
+	return NewProblem(http.StatusInternalServerError, CodeInternal, "Internal server error")
+}
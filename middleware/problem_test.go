@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindingRequest exercises the validation rules the controllers use
+type bindingRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantErrors []FieldError // Message is only checked to be non-empty
+	}{
+		{
+			name: "problem is rendered as is",
+			handler: func(c *gin.Context) {
+				c.Error(NewProblem(http.StatusForbidden, CodeForbidden, "Insufficient permissions"))
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   CodeForbidden,
+			wantDetail: "Insufficient permissions",
+		},
+		{
+			name: "validation errors list every rejected field",
+			body: `{"email":"not-an-email","password":"short"}`,
+			handler: func(c *gin.Context) {
+				var req bindingRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Error(err).SetType(gin.ErrorTypeBind)
+				}
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantDetail: "Request validation failed",
+			wantErrors: []FieldError{{Field: "email", Code: "email"}, {Field: "password", Code: "min"}},
+		},
+		{
+			name: "missing required fields",
+			body: `{}`,
+			handler: func(c *gin.Context) {
+				var req bindingRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Error(err).SetType(gin.ErrorTypeBind)
+				}
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeValidationFailed,
+			wantDetail: "Request validation failed",
+			wantErrors: []FieldError{{Field: "email", Code: "required"}, {Field: "password", Code: "required"}},
+		},
+		{
+			name: "malformed body",
+			body: `{"email":`,
+			handler: func(c *gin.Context) {
+				var req bindingRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.Error(err).SetType(gin.ErrorTypeBind)
+				}
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeMalformedRequest,
+			wantDetail: "The request body or query could not be parsed",
+		},
+		{
+			name: "mapper is used for other errors",
+			handler: func(c *gin.Context) {
+				c.Error(errMapped)
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   "mapped",
+			wantDetail: "Mapped",
+		},
+		{
+			name: "unexpected errors become a generic 500",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+			wantDetail: "Internal server error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveProblem(tt.handler, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, ProblemContentType)
+			}
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+			}
+			if problem.Status != tt.wantStatus || problem.Code != tt.wantCode || problem.Detail != tt.wantDetail {
+				t.Errorf("problem = %d %q %q, want %d %q %q", problem.Status, problem.Code, problem.Detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+			if problem.Title != http.StatusText(tt.wantStatus) || problem.Instance != "/test" || problem.RequestID != "req-1" {
+				t.Errorf("title, instance, request ID = %q %q %q", problem.Title, problem.Instance, problem.RequestID)
+			}
+			if len(problem.Errors) != len(tt.wantErrors) {
+				t.Fatalf("errors = %+v, want %+v", problem.Errors, tt.wantErrors)
+			}
+			for i, want := range tt.wantErrors {
+				got := problem.Errors[i]
+				if got.Field != want.Field || got.Code != want.Code || got.Message == "" {
+					t.Errorf("errors[%d] = %+v, want field %q code %q with a message", i, got, want.Field, want.Code)
+				}
+			}
+			if strings.Contains(w.Body.String(), "10.0.0.5") {
+				t.Errorf("response leaks the error cause: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestErrorHandlerKeepsWrittenResponses(t *testing.T) {
+	w := serveProblem(func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+		c.Error(errors.New("late failure"))
+	}, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") == ProblemContentType {
+		t.Errorf("status = %d, Content-Type = %q, want the handler's response", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+// errMapped is recognized by testMapper
+var errMapped = errors.New("mapped")
+
+// testMapper maps errMapped to a 404
+func testMapper(err error) *Problem {
+	if errors.Is(err, errMapped) {
+		return NewProblem(http.StatusNotFound, "mapped", "Mapped")
+	}
+	return nil
+}
+
+// serveProblem runs handler behind the error handler and returns the response
+func serveProblem(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler(testMapper))
+	router.POST("/test", handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -2,13 +2,11 @@ package middleware
 
 import (
 	"context"
-	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/leventeberry/goapi/cache"
-	"github.com/leventeberry/goapi/config"
 )
 
 // RateLimiterConfig holds configuration for rate limiting
@@ -19,14 +17,15 @@ type RateLimiterConfig struct {
 
 // rateLimiterEntry tracks requests for a single IP
 type rateLimiterEntry struct {
-	tokens     int
-	lastUpdate time.Time
-	mu         sync.Mutex
+	bucket cache.LocalBucket
+	mu     sync.Mutex
 }
 
 // RateLimiter implements a token bucket rate limiter
+// Refills fractionally with the same math as the cache-backed RedisRateLimiter
 type RateLimiter struct {
 	config      RateLimiterConfig
+	bucket      cache.TokenBucket
 	entries     map[string]*rateLimiterEntry
 	mu          sync.RWMutex
 	cleanupTick *time.Ticker
@@ -36,6 +35,7 @@ type RateLimiter struct {
 func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 	rl := &RateLimiter{
 		config:  config,
+		bucket:  cache.PerMinute(config.RequestsPerMinute, config.BurstSize),
 		entries: make(map[string]*rateLimiterEntry),
 	}
 
@@ -53,7 +53,7 @@ func (rl *RateLimiter) cleanup() {
 		now := time.Now()
 		for ip, entry := range rl.entries {
 			entry.mu.Lock()
-			if now.Sub(entry.lastUpdate) > 10*time.Minute {
+			if now.Sub(entry.bucket.LastUsed()) > 10*time.Minute {
 				delete(rl.entries, ip)
 			}
 			entry.mu.Unlock()
@@ -62,57 +62,48 @@ func (rl *RateLimiter) cleanup() {
 	}
 }
 
-// allow checks if a request from the given IP should be allowed
-func (rl *RateLimiter) allow(ip string) bool {
+// allow checks if a request from the given key should be allowed
+func (rl *RateLimiter) allow(ctx context.Context, key string) RateLimitDecision {
+	return rl.allowAt(key, time.Now())
+}
+
+// allowAt checks a request from key made at now
+func (rl *RateLimiter) allowAt(key string, now time.Time) RateLimitDecision {
 	rl.mu.Lock()
-	entry, exists := rl.entries[ip]
+	entry, exists := rl.entries[key]
 	if !exists {
-		entry = &rateLimiterEntry{
-			tokens:     rl.config.BurstSize,
-			lastUpdate: time.Now(),
-		}
-		rl.entries[ip] = entry
+		entry = &rateLimiterEntry{}
+		rl.entries[key] = entry
 	}
 	rl.mu.Unlock()
 
 	entry.mu.Lock()
 	defer entry.mu.Unlock()
 
-	now := time.Now()
-	elapsed := now.Sub(entry.lastUpdate)
-
-	// Refill tokens based on time elapsed
-	tokensToAdd := int(elapsed.Minutes() * float64(rl.config.RequestsPerMinute))
-	if tokensToAdd > 0 {
-		entry.tokens = min(entry.tokens+tokensToAdd, rl.config.BurstSize)
-		entry.lastUpdate = now
-	}
-
-	// Check if we have tokens available
-	if entry.tokens > 0 {
-		entry.tokens--
-		return true
+	result := entry.bucket.Take(rl.bucket, now)
+	return RateLimitDecision{
+		Allowed:    result.Allowed,
+		Limit:      rl.config.BurstSize,
+		Remaining:  result.Remaining,
+		RetryAfter: result.RetryAfter,
+		ResetAfter: result.ResetAfter,
 	}
-
-	return false
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+// RateLimitDecision is the outcome of a rate limit check
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int           // requests allowed in a burst; zero if the limiter could not decide
+	Remaining  int           // requests left right now
+	RetryAfter time.Duration // time until the next request is allowed, zero if allowed
+	ResetAfter time.Duration // time until the full burst is available again
 }
 
-var (
-	// globalRateLimiter is a singleton in-memory rate limiter instance
-	globalRateLimiter *RateLimiter
-	// globalRedisRateLimiter is a Redis-based rate limiter instance
-	globalRedisRateLimiter *RedisRateLimiter
-	rateLimiterOnce         sync.Once
-	useRedis                bool
-)
+// limiter decides whether a request identified by key may proceed
+// Implemented by the in-memory RateLimiter and the cache-backed RedisRateLimiter
+type limiter interface {
+	allow(ctx context.Context, key string) RateLimitDecision
+}
 
 // RateLimitMiddleware returns a middleware that rate limits requests per IP
 // Default: 60 requests per minute with burst of 10
@@ -124,74 +115,26 @@ func RateLimitMiddleware() gin.HandlerFunc {
 // RateLimitMiddlewareWithCache returns a middleware that rate limits requests per IP
 // If cacheClient is provided and not a no-op cache, uses Redis-based rate limiting
 // Otherwise falls back to in-memory rate limiting
-// Default: 60 requests per minute with burst of 10
+// Equivalent to NewRateLimiters(cacheClient).Middleware(RateLimitPolicyGlobal)
 func RateLimitMiddlewareWithCache(cacheClient cache.Cache) gin.HandlerFunc {
-	// Initialize rate limiter once (singleton pattern)
-	rateLimiterOnce.Do(func() {
-		// Load configuration from centralized config
-		cfg := config.Get()
-		rateLimitConfig := RateLimiterConfig{
-			RequestsPerMinute: cfg.RateLimit.RequestsPerMinute,
-			BurstSize:         cfg.RateLimit.BurstSize,
-		}
-
-		// Check if we should use Redis
-		// Use Redis if cache is provided and it's actually working (not no-op)
-		if cacheClient != nil {
-			// Test if cache is actually working by trying to set/get a test value
-			// No-op cache will return cache miss, Redis will work
-			ctx := context.Background()
-			testKey := "ratelimit:init:test"
-			testValue := "test"
-			
-				// Try to set and get - if this works, we have a real cache
-				err := cacheClient.Set(ctx, testKey, testValue, time.Second)
-				if err == nil {
-					val, err := cacheClient.Get(ctx, testKey)
-					if err == nil && val == testValue {
-						// Cache is working (Redis is available), use Redis rate limiter
-						globalRedisRateLimiter = NewRedisRateLimiter(cacheClient, rateLimitConfig)
-						useRedis = true
-						// Clean up test key
-						cacheClient.Delete(ctx, testKey)
-					} else {
-						// Cache not working (no-op cache), use in-memory
-						globalRateLimiter = NewRateLimiter(rateLimitConfig)
-						useRedis = false
-					}
-				} else {
-					// Cache not working, use in-memory
-					globalRateLimiter = NewRateLimiter(rateLimitConfig)
-					useRedis = false
-				}
-			} else {
-				// No cache provided, use in-memory
-				globalRateLimiter = NewRateLimiter(rateLimitConfig)
-				useRedis = false
-			}
-	})
-
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		ctx := c.Request.Context()
-
-		var allowed bool
-		if useRedis && globalRedisRateLimiter != nil {
-			// Use Redis-based rate limiting
-			allowed = globalRedisRateLimiter.allow(ctx, clientIP)
-		} else {
-			// Use in-memory rate limiting
-			allowed = globalRateLimiter.allow(clientIP)
-		}
+	return NewRateLimiters(cacheClient).Middleware(RateLimitPolicyGlobal)
+}
 
-		if !allowed {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please try again later.",
-			})
-			return
-		}
+// cacheUsable reports whether cacheClient actually stores values
+// No-op cache will return cache miss, Redis and the in-memory cache will work
+func cacheUsable(cacheClient cache.Cache) bool {
+	if cacheClient == nil {
+		return false
+	}
 
-		c.Next()
+	ctx := context.Background()
+	testKey := "ratelimit:init:test"
+	testValue := "test"
+	if err := cacheClient.Set(ctx, testKey, testValue, time.Second); err != nil {
+		return false
 	}
-}
+	defer cacheClient.Delete(ctx, testKey)
 
+	val, err := cacheClient.Get(ctx, testKey)
+	return err == nil && val == testValue
+}
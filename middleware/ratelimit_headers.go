@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rate limit header styles (RATE_LIMIT_HEADERS)
+const (
+	// RateLimitHeadersIETF emits RateLimit-Limit/Remaining/Reset from the IETF httpapi draft
+	// RateLimit-Reset is the number of seconds until the quota is fully restored
+	RateLimitHeadersIETF = "ietf"
+	// RateLimitHeadersLegacy emits X-RateLimit-Limit/Remaining/Reset
+	// X-RateLimit-Reset is the Unix time at which the quota is fully restored
+	RateLimitHeadersLegacy = "legacy"
+	// RateLimitHeadersBoth emits both header sets
+	RateLimitHeadersBoth = "both"
+	// RateLimitHeadersNone disables quota headers (Retry-After is still sent on 429s)
+	RateLimitHeadersNone = "none"
+)
+
+// rateLimitDecisionKey stores the most restrictive decision applied to the request so far
+const rateLimitDecisionKey = "rateLimitDecision"
+
+// setRateLimitHeaders reports a decision to the client
+// When several policies apply (e.g. global and per-user), the one with the fewest remaining requests wins
+func setRateLimitHeaders(c *gin.Context, style string, decision RateLimitDecision) {
+	if !decision.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter, 1)))
+	}
+	if decision.Limit == 0 || style == RateLimitHeadersNone {
+		return
+	}
+
+	if previous, ok := c.Get(rateLimitDecisionKey); ok && previous.(RateLimitDecision).Remaining < decision.Remaining {
+		return
+	}
+	c.Set(rateLimitDecisionKey, decision)
+
+	limit := strconv.Itoa(decision.Limit)
+	remaining := strconv.Itoa(decision.Remaining)
+	if style == RateLimitHeadersIETF || style == RateLimitHeadersBoth {
+		c.Header("RateLimit-Limit", limit)
+		c.Header("RateLimit-Remaining", remaining)
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter, 0)))
+	}
+	if style == RateLimitHeadersLegacy || style == RateLimitHeadersBoth {
+		c.Header("X-RateLimit-Limit", limit)
+		c.Header("X-RateLimit-Remaining", remaining)
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(decision.ResetAfter).Unix(), 10))
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, never returning less than minimum
+func ceilSeconds(d time.Duration, minimum int) int {
+	return max(int(math.Ceil(d.Seconds())), minimum)
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/metrics"
+)
+
+// Built-in rate limit policy names
+const (
+	// RateLimitPolicyGlobal limits every request per client IP (health checks and metrics exempt)
+	RateLimitPolicyGlobal = "global"
+	// RateLimitPolicyAuth is the strict per-IP limit for login and registration
+	RateLimitPolicyAuth = "auth"
+	// RateLimitPolicyUser limits authenticated routes per user, with a higher tier for admins
+	RateLimitPolicyUser = "user"
+)
+
+// RateLimitKeyFunc returns the identity a request is limited by
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitPolicy describes how a group of routes is rate limited
+type RateLimitPolicy struct {
+	// Name identifies the policy and namespaces its buckets, so policies never share quota
+	Name string
+	// Limit applies unless a RoleLimits entry matches
+	Limit RateLimiterConfig
+	// RoleLimits overrides Limit for JWT roles (requires AuthMiddleware to run first)
+	RoleLimits map[string]RateLimiterConfig
+	// Key picks the identity to limit by; defaults to KeyByIP
+	Key RateLimitKeyFunc
+	// Skip exempts matching requests from the policy
+	Skip func(c *gin.Context) bool
+}
+
+// ipKeyPrefix marks rate limit keys that identify a client IP group
+const ipKeyPrefix = "ip:"
+
+// KeyByIP limits by client IP, with IPv6 clients grouped by prefix (see ClientIPGroup)
+func KeyByIP(c *gin.Context) string {
+	return ipKeyPrefix + ClientIPGroup(c)
+}
+
+// KeyByUser limits by the JWT subject set by AuthMiddleware, falling back to the client IP
+func KeyByUser(c *gin.Context) string {
+	if userID := c.GetString("userID"); userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(c)
+}
+
+// SkipPaths exempts the given route templates (as returned by c.FullPath) from a policy
+func SkipPaths(paths ...string) func(c *gin.Context) bool {
+	skip := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		skip[path] = true
+	}
+	return func(c *gin.Context) bool {
+		return skip[c.FullPath()]
+	}
+}
+
+// DefaultRateLimitPolicies returns the built-in policies configured from RATE_LIMIT_* settings
+func DefaultRateLimitPolicies(cfg *config.Config) []RateLimitPolicy {
+	return []RateLimitPolicy{
+		{
+			Name:  RateLimitPolicyGlobal,
+			Limit: RateLimiterConfig{RequestsPerMinute: cfg.RateLimit.RequestsPerMinute, BurstSize: cfg.RateLimit.BurstSize},
+			Key:   KeyByIP,
+			Skip:  SkipPaths("/health", "/livez", "/readyz", "/startupz", "/metrics"),
+		},
+		{
+			Name:  RateLimitPolicyAuth,
+			Limit: tierConfig(cfg.RateLimit.Auth),
+			Key:   KeyByIP,
+		},
+		{
+			Name:  RateLimitPolicyUser,
+			Limit: tierConfig(cfg.RateLimit.User),
+			RoleLimits: map[string]RateLimiterConfig{
+				"admin": tierConfig(cfg.RateLimit.Admin),
+			},
+			Key: KeyByUser,
+		},
+	}
+}
+
+// tierConfig converts a configured tier to a limiter configuration
+func tierConfig(tier config.RateLimitTier) RateLimiterConfig {
+	return RateLimiterConfig{RequestsPerMinute: tier.RequestsPerMinute, BurstSize: tier.BurstSize}
+}
+
+// RateLimiters builds rate limit middleware for named policies
+// Limiters share the cache when it is usable, otherwise each policy limits in memory
+type RateLimiters struct {
+	cache    cache.Cache // nil when limiting in memory
+	failOpen bool
+	headers  string // RateLimitHeaders* style
+	mu       sync.Mutex
+	policies map[string]RateLimitPolicy
+	handlers map[string]gin.HandlerFunc
+	onReject []RateLimitRejectFunc
+}
+
+// RateLimitRejectFunc is called after a policy rejects a request
+// key is the identity the policy limited by, as returned by its RateLimitKeyFunc
+type RateLimitRejectFunc func(c *gin.Context, policy, key string)
+
+// rateLimitExemptKey marks requests that bypass every policy (set by IPFilter for allowlisted clients)
+const rateLimitExemptKey = "rateLimitExempt"
+
+// NewRateLimiters creates a registry holding the default policies
+// Uses cacheClient for distributed limiting if it is a working cache
+func NewRateLimiters(cacheClient cache.Cache) *RateLimiters {
+	cfg := config.Get()
+	r := &RateLimiters{
+		failOpen: cfg.RateLimit.FailOpen,
+		headers:  cfg.RateLimit.Headers,
+		policies: make(map[string]RateLimitPolicy),
+		handlers: make(map[string]gin.HandlerFunc),
+	}
+	if cacheUsable(cacheClient) {
+		r.cache = cacheClient
+	}
+	for _, policy := range DefaultRateLimitPolicies(cfg) {
+		r.Register(policy)
+	}
+	return r
+}
+
+// Register adds or replaces a named policy
+// Middleware already created for the policy keeps its previous settings
+func (r *RateLimiters) Register(policy RateLimitPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[policy.Name] = policy
+	delete(r.handlers, policy.Name)
+}
+
+// OnReject registers a callback for rejected requests, e.g. to ban repeat offenders
+func (r *RateLimiters) OnReject(fn RateLimitRejectFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReject = append(r.onReject, fn)
+}
+
+// rejected counts the rejection and runs the OnReject callbacks
+func (r *RateLimiters) rejected(c *gin.Context, policy, key string) {
+	metrics.RateLimitRejections.WithLabelValues(policy).Inc()
+
+	r.mu.Lock()
+	callbacks := r.onReject
+	r.mu.Unlock()
+	for _, fn := range callbacks {
+		fn(c, policy, key)
+	}
+}
+
+// Middleware returns a handler enforcing the named policy
+// Attach it to any gin.RouterGroup; every group using the same policy shares its quota
+// Panics if the policy was never registered
+func (r *RateLimiters) Middleware(name string) gin.HandlerFunc {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if handler, ok := r.handlers[name]; ok {
+		return handler
+	}
+	policy, ok := r.policies[name]
+	if !ok {
+		panic(fmt.Sprintf("rate limit policy %q is not registered", name))
+	}
+	handler := r.handler(policy)
+	r.handlers[name] = handler
+	return handler
+}
+
+// newLimiter creates the limiter for one tier of a policy
+func (r *RateLimiters) newLimiter(limit RateLimiterConfig) limiter {
+	if r.cache != nil {
+		return NewRedisRateLimiter(r.cache, limit, r.failOpen)
+	}
+	return NewRateLimiter(limit)
+}
+
+// handler builds the middleware for a policy
+func (r *RateLimiters) handler(policy RateLimitPolicy) gin.HandlerFunc {
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	defaultLimiter := r.newLimiter(policy.Limit)
+	roleLimiters := make(map[string]limiter, len(policy.RoleLimits))
+	for role, limit := range policy.RoleLimits {
+		roleLimiters[role] = r.newLimiter(limit)
+	}
+
+	return func(c *gin.Context) {
+		if c.GetBool(rateLimitExemptKey) || (policy.Skip != nil && policy.Skip(c)) {
+			c.Next()
+			return
+		}
+
+		l := defaultLimiter
+		if roleLimiter, ok := roleLimiters[c.GetString("role")]; ok {
+			l = roleLimiter
+		}
+
+		key := keyFunc(c)
+		decision := l.allow(c.Request.Context(), policy.Name+":"+key)
+		setRateLimitHeaders(c, r.headers, decision)
+		if !decision.Allowed {
+			r.rejected(c, policy.Name, key)
+			AbortWithProblem(c, NewProblem(http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded. Please try again later."))
+			return
+		}
+
+		c.Next()
+	}
+}
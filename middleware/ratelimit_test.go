@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leventeberry/goapi/cache"
+)
+
+func TestLimitersAgreeOnBurst(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   RateLimiterConfig
+		requests int
+	}{
+		{"small burst", RateLimiterConfig{RequestsPerMinute: 1, BurstSize: 3}, 6},
+		{"single token", RateLimiterConfig{RequestsPerMinute: 1, BurstSize: 1}, 3},
+		{"default policy", RateLimiterConfig{RequestsPerMinute: 60, BurstSize: 10}, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			inMemory := NewRateLimiter(tt.config)
+			defer inMemory.cleanupTick.Stop()
+			cacheBacked := NewRedisRateLimiter(cache.NewMemoryCache(100), tt.config, false)
+
+			for i := 0; i < tt.requests; i++ {
+				got, want := inMemory.allow(ctx, "client"), cacheBacked.allow(ctx, "client")
+				if got.Allowed != want.Allowed || got.Remaining != want.Remaining || got.Limit != want.Limit {
+					t.Fatalf("request %d: in-memory %+v, cache-backed %+v", i, got, want)
+				}
+				if wantAllowed := i < tt.config.BurstSize; got.Allowed != wantAllowed {
+					t.Fatalf("request %d: allowed = %v, want %v", i, got.Allowed, wantAllowed)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	type step struct {
+		offset    time.Duration
+		allowed   bool
+		remaining int
+	}
+	tests := []struct {
+		name   string
+		config RateLimiterConfig
+		steps  []step
+	}{
+		{
+			// Refilling whole tokens only would lose the half token earned before 1.5s
+			name:   "keeps fractional refill progress",
+			config: RateLimiterConfig{RequestsPerMinute: 60, BurstSize: 2},
+			steps: []step{
+				{0, true, 1},
+				{0, true, 0},
+				{1500 * time.Millisecond, true, 0},
+				{2 * time.Second, true, 0},
+				{2500 * time.Millisecond, false, 0},
+			},
+		},
+		{
+			name:   "denied requests do not reset refill",
+			config: RateLimiterConfig{RequestsPerMinute: 60, BurstSize: 1},
+			steps: []step{
+				{0, true, 0},
+				{400 * time.Millisecond, false, 0},
+				{800 * time.Millisecond, false, 0},
+				{time.Second, true, 0},
+			},
+		},
+		{
+			name:   "refill is capped at burst",
+			config: RateLimiterConfig{RequestsPerMinute: 120, BurstSize: 2},
+			steps: []step{
+				{0, true, 1},
+				{0, true, 0},
+				{time.Hour, true, 1},
+				{time.Hour, true, 0},
+				{time.Hour, false, 0},
+			},
+		},
+	}
+
+	start := time.Unix(1700000000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(tt.config)
+			defer limiter.cleanupTick.Stop()
+			bucket := cache.PerMinute(tt.config.RequestsPerMinute, tt.config.BurstSize)
+			var shared cache.LocalBucket
+
+			for i, s := range tt.steps {
+				now := start.Add(s.offset)
+				got := limiter.allowAt("client", now)
+				if got.Allowed != s.allowed || got.Remaining != s.remaining {
+					t.Fatalf("step %d at %v: allowed=%v remaining=%d, want allowed=%v remaining=%d",
+						i, s.offset, got.Allowed, got.Remaining, s.allowed, s.remaining)
+				}
+				want := shared.Take(bucket, now)
+				if got.RetryAfter != want.RetryAfter || got.ResetAfter != want.ResetAfter {
+					t.Fatalf("step %d: timings %v/%v, token bucket %v/%v", i, got.RetryAfter, got.ResetAfter, want.RetryAfter, want.ResetAfter)
+				}
+			}
+		})
+	}
+}
@@ -2,47 +2,43 @@ package middleware
 
 import (
 	"context"
-	"time"
 
 	"github.com/leventeberry/goapi/cache"
+	"github.com/rs/zerolog"
 )
 
 // RedisRateLimiter implements rate limiting using Redis
-// Uses a sliding window counter approach suitable for distributed systems
+// Uses an atomic token bucket (a Lua script in Redis) that behaves like the in-memory RateLimiter:
+// each key may burst up to BurstSize requests, refilled at RequestsPerMinute
 type RedisRateLimiter struct {
-	cache            cache.Cache
-	requestsPerMinute int
-	burstSize         int
-	window            time.Duration
+	cache    cache.Cache
+	bucket   cache.TokenBucket
+	failOpen bool
 }
 
 // NewRedisRateLimiter creates a new Redis-based rate limiter
-func NewRedisRateLimiter(cacheClient cache.Cache, config RateLimiterConfig) *RedisRateLimiter {
+// failOpen decides whether requests are allowed when the cache is unavailable
+func NewRedisRateLimiter(cacheClient cache.Cache, config RateLimiterConfig, failOpen bool) *RedisRateLimiter {
 	return &RedisRateLimiter{
-		cache:             cacheClient,
-		requestsPerMinute: config.RequestsPerMinute,
-		burstSize:         config.BurstSize,
-		window:            cache.RateLimitWindow,
+		cache:    cacheClient,
+		bucket:   cache.PerMinute(config.RequestsPerMinute, config.BurstSize),
+		failOpen: failOpen,
 	}
 }
 
 // allow checks if a request from the given key should be allowed
-// Uses Redis INCR with expiration for distributed rate limiting
-func (r *RedisRateLimiter) allow(ctx context.Context, key string) bool {
-	// Increment the counter for this key
-	count, err := r.cache.IncrementRateLimit(ctx, key, r.window)
+// On backend errors the decision follows the failure policy and carries no quota information
+func (r *RedisRateLimiter) allow(ctx context.Context, key string) RateLimitDecision {
+	result, err := r.cache.TakeToken(ctx, key, r.bucket)
 	if err != nil {
-		// If Redis fails, allow the request (fail open)
-		// In production, you might want to log this
-		return true
+		zerolog.Ctx(ctx).Warn().Err(err).Str("key", key).Bool("fail_open", r.failOpen).Msg("Rate limiter backend error")
+		return RateLimitDecision{Allowed: r.failOpen}
 	}
-
-	// Check if count exceeds the limit
-	// We use requestsPerMinute as the limit
-	if count > r.requestsPerMinute {
-		return false
+	return RateLimitDecision{
+		Allowed:    result.Allowed,
+		Limit:      r.bucket.Burst,
+		Remaining:  result.Remaining,
+		RetryAfter: result.RetryAfter,
+		ResetAfter: result.ResetAfter,
 	}
-
-	return true
 }
-
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/leventeberry/goapi/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request/correlation ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds accepted client IDs so they can't bloat every log line
+const maxRequestIDLength = 128
+
+// requestIDKey stores the request ID in the Gin context
+const requestIDKey = "request_id"
+
+// requestIDContextKey stores the request ID in the request context, for code without a *gin.Context
+type requestIDContextKey struct{}
+
+// RequestID returns a middleware that assigns every request an ID and a request-scoped logger
+// A valid incoming X-Request-ID is reused so IDs correlate across services; otherwise a UUID is generated
+// The ID is echoed in the X-Request-ID response header, and zerolog.Ctx(ctx) returns a logger tagged with it
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		ctx := c.Request.Context()
+		logCtx := logger.Log.With().Str("request_id", id)
+		if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+			logCtx = logCtx.Str("trace_id", spanCtx.TraceID().String())
+		}
+		requestLogger := logCtx.Logger()
+
+		ctx = context.WithValue(ctx, requestIDContextKey{}, id)
+		c.Request = c.Request.WithContext(requestLogger.WithContext(ctx))
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by RequestID (empty if the middleware did not run)
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// RequestIDFromContext returns the request ID stored in a request context
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// validRequestID accepts short IDs made of printable ASCII without spaces, so they are safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
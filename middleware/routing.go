@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/repositories"
+)
+
+// ReadYourWrites returns a middleware that prepares each request for replica routing.
+// After a repository write, later reads in the same request go to the primary
+// so clients never see their own write missing because of replica lag.
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(repositories.WithReadYourWrites(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths are probe and scrape endpoints that would only add noise to traces
+var untracedPaths = map[string]bool{
+	"/health":   true,
+	"/livez":    true,
+	"/readyz":   true,
+	"/startupz": true,
+	"/metrics":  true,
+}
+
+// Tracing returns a middleware that starts a server span per request
+// An incoming W3C traceparent header continues the caller's trace; the span is named after the route template
+func Tracing(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName,
+		otelgin.WithGinFilter(func(c *gin.Context) bool {
+			return !untracedPaths[c.Request.URL.Path]
+		}),
+	)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/leventeberry/goapi/i18n"
+	"github.com/leventeberry/goapi/logger"
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Field errors name fields the way clients send them, not by their Go names
+	v.RegisterTagNameFunc(requestFieldName)
+	if err := i18n.RegisterValidator(v); err != nil {
+		logger.Log.Warn().Err(err).Msg("Validation messages will not be translated")
+	}
+}
+
+// requestFieldName returns the JSON name of a struct field, or its query/form name for query structs
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// bindingProblem converts a ShouldBind error to a problem with messages in locale
+// Validator messages are rebuilt per field, so raw validator text never reaches clients
+func bindingProblem(err error, locale string) *Problem {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return NewProblem(http.StatusBadRequest, CodeMalformedRequest, "The request body or query could not be parsed")
+	}
+
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   fieldErr.Field(),
+			Code:    fieldErr.Tag(),
+			Message: validationMessage(fieldErr, locale),
+		})
+	}
+	return ValidationProblem(fieldErrors...)
+}
+
+// validationMessage describes a failed validation rule; the field code is the rule itself
+// Rules without a message in locale fall back to English
+func validationMessage(fieldErr validator.FieldError, locale string) string {
+	if message, ok := i18n.FieldError(locale, fieldErr); ok {
+		return message
+	}
+	if message, ok := i18n.FieldError(i18n.English, fieldErr); ok {
+		return message
+	}
+	return fieldErr.Field() + " is invalid"
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditEventImmutable is returned when code tries to modify or delete an audit event
+var ErrAuditEventImmutable = errors.New("audit events are append-only")
+
+// Audit actions recorded by the services
+const (
+	AuditActionUserCreate     = "user.create"
+	AuditActionUserUpdate     = "user.update"
+	AuditActionUserRoleChange = "user.role_change"
+	AuditActionUserDelete     = "user.delete"
+	AuditActionRegister       = "auth.register"
+	AuditActionLogin          = "auth.login"
+	AuditActionLoginFailed    = "auth.login_failed"
+)
+
+// Audit target types
+const (
+	AuditTargetUser = "user"
+	// AuditTargetEmail identifies failed logins, which may not match any user
+	AuditTargetEmail = "email"
+)
+
+// AuditEvent is one security-relevant action, stored in the append-only audit_events table
+type AuditEvent struct {
+	ID         int64     `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"index;not null" json:"created_at"`
+	Action     string    `gorm:"size:64;index;not null" json:"action"`
+	ActorID    *int      `gorm:"index" json:"actor_id,omitempty"` // Nil for anonymous actions such as login and register
+	ActorRole  string    `gorm:"size:20" json:"actor_role,omitempty"`
+	TargetType string    `gorm:"size:20;index:idx_audit_events_target" json:"target_type"`
+	TargetID   string    `gorm:"size:255;index:idx_audit_events_target" json:"target_id"` // Fits the longest accepted email
+	// Changes maps each changed field to its before/after values; secrets are only flagged as changed
+	Changes   json.RawMessage `gorm:"type:jsonb" json:"changes,omitempty"`
+	IP        string          `gorm:"size:45" json:"ip,omitempty"`
+	RequestID string          `gorm:"size:128;index" json:"request_id,omitempty"`
+}
+
+// BeforeUpdate keeps the audit log append-only
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// BeforeDelete keeps the audit log append-only
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+// AuditEventTriggerSQL enforces the append-only rule in the database, so raw SQL and other clients are refused too
+// Run the statements in order in one transaction after AutoMigrate; schema.sql holds the same definitions
+// The advisory lock serializes instances starting together, and existing triggers are left in place so the rule never lapses
+var AuditEventTriggerSQL = []string{
+	`SELECT pg_advisory_xact_lock(hashtext('audit_events_append_only'))`,
+	`CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only' USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql`,
+	`DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgrelid = 'audit_events'::regclass AND tgname = 'audit_events_append_only') THEN
+        CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+            FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgrelid = 'audit_events'::regclass AND tgname = 'audit_events_no_truncate') THEN
+        CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+            FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+    END IF;
+END;
+$$`,
+}
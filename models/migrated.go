@@ -0,0 +1,10 @@
+package models
+
+// Migrated returns the models whose tables are managed by AutoMigrate
+// Add new models here; the startup probe checks that each table exists
+func Migrated() []any {
+	return []any{
+		&User{},
+		&AuditEvent{},
+	}
+}
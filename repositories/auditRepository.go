@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leventeberry/goapi/models"
+	"gorm.io/gorm"
+)
+
+// auditExportBatchSize is the number of events Each reads per query
+const auditExportBatchSize = 500
+
+// auditRepository implements AuditRepository interface
+// Events are written to the primary (inside the caller's transaction when created from a UnitOfWork);
+// queries are served by read replicas
+type auditRepository struct {
+	db           *DBResolver
+	forcePrimary bool
+}
+
+// NewAuditRepository creates a new instance of AuditRepository
+// Factory function for creating audit repository
+func NewAuditRepository(db *DBResolver) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// reader returns the connection used for audit queries, bound to ctx
+func (r *auditRepository) reader(ctx context.Context) *gorm.DB {
+	if r.forcePrimary || UsePrimary(ctx) {
+		return r.db.Primary().WithContext(ctx)
+	}
+	return r.db.Replica().WithContext(ctx)
+}
+
+// Create appends an event to the audit log
+func (r *auditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
+	if err := r.db.Primary().WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create audit event %s: %w", event.Action, translateError(err))
+	}
+	MarkWritten(ctx)
+	return nil
+}
+
+// FindWithPagination retrieves matching audit events, newest first
+func (r *auditRepository) FindWithPagination(ctx context.Context, filter AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
+	var events []models.AuditEvent
+	var total int64
+
+	// Use the same connection for both queries so the count matches the page
+	db := r.reader(ctx)
+
+	if err := applyAuditFilter(db.Model(&models.AuditEvent{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := applyAuditFilter(db, filter).Order("id DESC").Offset(offset).Limit(pageSize).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to find audit events (page %d, pageSize %d): %w", page, pageSize, err)
+	}
+
+	return events, total, nil
+}
+
+// Each streams matching audit events to fn, oldest first
+// Batches are paged by ID rather than offset, and each batch gets its own query timeout,
+// so exporting a large log neither slows down nor times out as it progresses
+func (r *auditRepository) Each(ctx context.Context, filter AuditFilter, fn func(event *models.AuditEvent) error) error {
+	var lastID int64
+	for {
+		batch, err := r.nextBatch(ctx, filter, lastID)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < auditExportBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// nextBatch reads the next batch of matching events with an ID above afterID
+func (r *auditRepository) nextBatch(ctx context.Context, filter AuditFilter, afterID int64) ([]models.AuditEvent, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
+	var batch []models.AuditEvent
+	query := applyAuditFilter(r.reader(ctx), filter).Where("id > ?", afterID)
+	if err := query.Order("id ASC").Limit(auditExportBatchSize).Find(&batch).Error; err != nil {
+		return nil, fmt.Errorf("failed to read audit events after ID %d: %w", afterID, err)
+	}
+	return batch, nil
+}
+
+// applyAuditFilter adds a WHERE clause for each filter field that is set
+func applyAuditFilter(db *gorm.DB, filter AuditFilter) *gorm.DB {
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		db = db.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		db = db.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		db = db.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.IP != "" {
+		db = db.Where("ip = ?", filter.IP)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where("created_at < ?", filter.To)
+	}
+	return db
+}
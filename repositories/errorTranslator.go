@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL error codes for integrity constraint violations
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// ConstraintError describes a database constraint violation
+// errors.Is matches it against its Kind (ErrUniqueViolation, ErrForeignKeyViolation, ...)
+type ConstraintError struct {
+	Kind       error
+	Table      string
+	Column     string
+	Constraint string
+	Err        error
+}
+
+// Error returns a description of the violated constraint
+func (e *ConstraintError) Error() string {
+	switch {
+	case e.Constraint != "":
+		return fmt.Sprintf("%s: constraint %q on table %q", e.Kind, e.Constraint, e.Table)
+	case e.Column != "":
+		return fmt.Sprintf("%s: column %q on table %q", e.Kind, e.Column, e.Table)
+	default:
+		return e.Kind.Error()
+	}
+}
+
+// Unwrap exposes both the violation kind and the underlying driver error
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// translateError converts PostgreSQL constraint violations into a *ConstraintError
+// Any other error is returned unchanged
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		kind = ErrUniqueViolation
+	case pgForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case pgCheckViolation:
+		kind = ErrCheckViolation
+	case pgNotNullViolation:
+		kind = ErrNotNullViolation
+	default:
+		return err
+	}
+
+	return &ConstraintError{
+		Kind:       kind,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		Constraint: pgErr.ConstraintName,
+		Err:        err,
+	}
+}
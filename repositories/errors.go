@@ -8,3 +8,10 @@ var (
 	ErrUserExists   = errors.New("user already exists")
 )
 
+// Constraint violation errors, returned wrapped in a *ConstraintError
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+	ErrCheckViolation      = errors.New("check constraint violation")
+	ErrNotNullViolation    = errors.New("not-null constraint violation")
+)
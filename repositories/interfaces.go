@@ -1,16 +1,50 @@
 package repositories
 
-import "github.com/leventeberry/goapi/models"
+import (
+	"context"
+	"time"
+
+	"github.com/leventeberry/goapi/models"
+)
 
 // UserRepository defines the interface for user data operations
+// Every method honors ctx cancellation so abandoned requests stop their queries
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByID(id int) (*models.User, error)
-	FindByEmail(email string) (*models.User, error)
-	FindAll() ([]models.User, error)
-	FindAllWithPagination(page, pageSize int) ([]models.User, int64, error)
-	Update(user *models.User) error
-	Delete(id int) error
-	ExistsByEmail(email string) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id int) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// FindByIDForUpdate locks the row with SELECT ... FOR UPDATE until the transaction ends
+	// Only meaningful on a repository obtained from a UnitOfWork
+	FindByIDForUpdate(ctx context.Context, id int) (*models.User, error)
+	FindAll(ctx context.Context) ([]models.User, error)
+	FindAllWithPagination(ctx context.Context, page, pageSize int) ([]models.User, int64, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id int) error
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+
+	// WithPrimary returns a repository whose reads bypass the read replicas
+	WithPrimary() UserRepository
 }
 
+// AuditFilter narrows an audit log query; zero values match everything
+type AuditFilter struct {
+	Action     string
+	ActorID    *int
+	TargetType string
+	TargetID   string
+	IP         string
+	RequestID  string
+	From       time.Time // Inclusive
+	To         time.Time // Exclusive
+}
+
+// AuditRepository defines the interface for the append-only audit log
+// There is deliberately no Update or Delete
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	// FindWithPagination returns matching events, newest first
+	FindWithPagination(ctx context.Context, filter AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error)
+	// Each calls fn for every matching event, oldest first, reading in batches
+	// It stops at the first error returned by fn
+	Each(ctx context.Context, filter AuditFilter, fn func(event *models.AuditEvent) error) error
+}
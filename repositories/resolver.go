@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DBResolver routes queries between the primary database and read replicas
+// Writes always go to the primary; reads are spread across replicas round-robin
+// Falls back to the primary when no replicas are configured
+type DBResolver struct {
+	primary      *gorm.DB
+	replicas     []*gorm.DB
+	next         atomic.Uint64
+	queryTimeout time.Duration
+}
+
+// NewDBResolver creates a resolver for the given primary and optional replicas
+func NewDBResolver(primary *gorm.DB, replicas ...*gorm.DB) *DBResolver {
+	return &DBResolver{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+// WithQueryTimeout sets the deadline applied to each repository call (0 disables it)
+// An earlier deadline already on the request context still wins
+func (r *DBResolver) WithQueryTimeout(timeout time.Duration) *DBResolver {
+	r.queryTimeout = timeout
+	return r
+}
+
+// queryContext derives the context for a single repository call
+func (r *DBResolver) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// Primary returns the primary (read-write) connection
+func (r *DBResolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Replica returns the next read replica, or the primary if there are none
+func (r *DBResolver) Replica() *gorm.DB {
+	if len(r.replicas) == 0 {
+		return r.primary
+	}
+	n := r.next.Add(1)
+	return r.replicas[(n-1)%uint64(len(r.replicas))]
+}
+
+// routingKey is the context key for request-scoped read routing state
+type routingKey struct{}
+
+// routingState records whether reads in a request must go to the primary
+type routingState struct {
+	mu      sync.Mutex
+	primary bool
+}
+
+// WithReadYourWrites returns a context that tracks writes made during a request
+// Once MarkWritten is called on it, UsePrimary reports true for the rest of the request
+// so that subsequent reads are not served stale data by a lagging replica
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(routingKey{}).(*routingState); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routingKey{}, &routingState{})
+}
+
+// ForcePrimary returns a context whose reads always go to the primary
+// Use it for read-modify-write flows or any call that cannot tolerate replica lag
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routingKey{}, &routingState{primary: true})
+}
+
+// MarkWritten records that the request performed a write
+// It is a no-op if the context was not prepared with WithReadYourWrites
+func MarkWritten(ctx context.Context) {
+	if state, ok := ctx.Value(routingKey{}).(*routingState); ok {
+		state.mu.Lock()
+		state.primary = true
+		state.mu.Unlock()
+	}
+}
+
+// UsePrimary reports whether reads for this context must go to the primary
+func UsePrimary(ctx context.Context) bool {
+	state, ok := ctx.Value(routingKey{}).(*routingState)
+	if !ok {
+		return false
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.primary
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// UnitOfWork exposes repositories that share a single database transaction
+// Everything done through it is committed or rolled back together
+type UnitOfWork interface {
+	Users() UserRepository
+	Audit() AuditRepository
+}
+
+// TransactionManager runs several repository calls atomically
+type TransactionManager interface {
+	// WithinTransaction runs fn inside a transaction on the primary database
+	// The transaction commits if fn returns nil and rolls back if it returns an error or panics
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context, uow UnitOfWork) error) error
+}
+
+// gormTransactionManager implements TransactionManager using GORM transactions
+type gormTransactionManager struct {
+	db *DBResolver
+}
+
+// NewTransactionManager creates a new instance of TransactionManager
+// Factory function for creating transaction manager
+func NewTransactionManager(db *DBResolver) TransactionManager {
+	return &gormTransactionManager{
+		db: db,
+	}
+}
+
+// WithinTransaction runs fn inside a database transaction
+func (m *gormTransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context, uow UnitOfWork) error) error {
+	return m.db.Primary().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Every read inside the transaction must see the transaction's own writes,
+		// so the transaction-bound resolver has no replicas
+		txResolver := NewDBResolver(tx).WithQueryTimeout(m.db.queryTimeout)
+		return fn(ctx, &unitOfWork{
+			users: &userRepository{db: txResolver, forcePrimary: true},
+			audit: &auditRepository{db: txResolver, forcePrimary: true},
+		})
+	})
+}
+
+// unitOfWork implements UnitOfWork for a single GORM transaction
+type unitOfWork struct {
+	users UserRepository
+	audit AuditRepository
+}
+
+// Users returns the user repository bound to the transaction
+func (u *unitOfWork) Users() UserRepository {
+	return u.users
+}
+
+// Audit returns the audit repository bound to the transaction
+// Events recorded through it are only kept if the audited change commits
+func (u *unitOfWork) Audit() AuditRepository {
+	return u.audit
+}
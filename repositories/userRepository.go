@@ -1,46 +1,90 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/leventeberry/goapi/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // userRepository implements UserRepository interface
+// Writes go to the primary; FindByID, FindAll, FindAllWithPagination and ExistsByEmail
+// are served by read replicas unless the repository or the request is pinned to the primary
 type userRepository struct {
-	db *gorm.DB
+	db           *DBResolver
+	forcePrimary bool
 }
 
 // NewUserRepository creates a new instance of UserRepository
 // Factory function for creating user repository
-func NewUserRepository(db *gorm.DB) UserRepository {
+func NewUserRepository(db *DBResolver) UserRepository {
 	return &userRepository{
 		db: db,
 	}
 }
 
+// WithPrimary returns a copy of the repository that sends every read to the primary
+func (r *userRepository) WithPrimary() UserRepository {
+	return &userRepository{
+		db:           r.db,
+		forcePrimary: true,
+	}
+}
+
+// writer returns the connection used for writes, bound to ctx
+func (r *userRepository) writer(ctx context.Context) *gorm.DB {
+	return r.db.Primary().WithContext(ctx)
+}
+
+// reader returns the connection used for replica-eligible reads, bound to ctx
+// Reads stick to the primary once the request has written (see MarkWritten)
+func (r *userRepository) reader(ctx context.Context) *gorm.DB {
+	if r.forcePrimary || UsePrimary(ctx) {
+		return r.db.Primary().WithContext(ctx)
+	}
+	return r.db.Replica().WithContext(ctx)
+}
+
+// translateWriteError maps constraint violations from a user write to repository errors
+// A unique violation (the only unique column is email) also matches ErrUserExists
+func translateWriteError(err error) error {
+	err = translateError(err)
+	if errors.Is(err, ErrUniqueViolation) {
+		return fmt.Errorf("%w: %w", ErrUserExists, err)
+	}
+	return err
+}
+
 // normalizeEmail normalizes email to lowercase and trims whitespace
 func normalizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // Create inserts a new user into the database
-func (r *userRepository) Create(user *models.User) error {
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	// Normalize email before saving
 	user.Email = normalizeEmail(user.Email)
-	if err := r.db.Create(user).Error; err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
+	if err := r.writer(ctx).Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", translateWriteError(err))
 	}
+	MarkWritten(ctx)
 	return nil
 }
 
 // FindByID retrieves a user by their ID
-func (r *userRepository) FindByID(id int) (*models.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.reader(ctx).First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -50,38 +94,66 @@ func (r *userRepository) FindByID(id int) (*models.User, error) {
 	return &user, nil
 }
 
+// FindByIDForUpdate retrieves a user by ID and locks the row for the rest of the transaction
+func (r *userRepository) FindByIDForUpdate(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
+	var user models.User
+	err := r.writer(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to lock user by ID %d: %w", id, err)
+	}
+	return &user, nil
+}
+
 // FindByEmail retrieves a user by their email
-func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	// Normalize email for case-insensitive lookup
 	email = normalizeEmail(email)
 	var user models.User
 	// Use LOWER() for defensive case-insensitive matching (handles existing mixed-case data)
-	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	err := r.writer(ctx).Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to find user by email %s: %w", email, err)
+		return nil, fmt.Errorf("failed to find user by email: %w", err)
 	}
 	return &user, nil
 }
 
 // FindAll retrieves all users from the database
-func (r *userRepository) FindAll() ([]models.User, error) {
+func (r *userRepository) FindAll(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	var users []models.User
-	if err := r.db.Find(&users).Error; err != nil {
+	if err := r.reader(ctx).Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to find all users: %w", err)
 	}
 	return users, nil
 }
 
 // FindAllWithPagination retrieves users with pagination support
-func (r *userRepository) FindAllWithPagination(page, pageSize int) ([]models.User, int64, error) {
+func (r *userRepository) FindAllWithPagination(ctx context.Context, page, pageSize int) ([]models.User, int64, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	var users []models.User
 	var total int64
 
+	// Use the same connection for both queries so the count matches the page
+	db := r.reader(ctx)
+
 	// Count total records
-	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := db.Model(&models.User{}).Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
 
@@ -89,7 +161,7 @@ func (r *userRepository) FindAllWithPagination(page, pageSize int) ([]models.Use
 	offset := (page - 1) * pageSize
 
 	// Retrieve paginated users
-	if err := r.db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+	if err := db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to find paginated users (page %d, pageSize %d): %w", page, pageSize, err)
 	}
 
@@ -98,21 +170,29 @@ func (r *userRepository) FindAllWithPagination(page, pageSize int) ([]models.Use
 
 // Update updates an existing user in the database
 // Uses Updates() instead of Save() to only update changed fields
-func (r *userRepository) Update(user *models.User) error {
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	// Normalize email before updating
 	user.Email = normalizeEmail(user.Email)
-	if err := r.db.Model(user).Updates(user).Error; err != nil {
-		return fmt.Errorf("failed to update user ID %d: %w", user.ID, err)
+	if err := r.writer(ctx).Model(user).Updates(user).Error; err != nil {
+		return fmt.Errorf("failed to update user ID %d: %w", user.ID, translateWriteError(err))
 	}
+	MarkWritten(ctx)
 	return nil
 }
 
 // Delete removes a user from the database
-func (r *userRepository) Delete(id int) error {
-	result := r.db.Delete(&models.User{}, id)
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
+	result := r.writer(ctx).Delete(&models.User{}, id)
 	if result.Error != nil {
-		return result.Error
+		return fmt.Errorf("failed to delete user ID %d: %w", id, translateError(result.Error))
 	}
+	MarkWritten(ctx)
 	if result.RowsAffected == 0 {
 		return ErrUserNotFound
 	}
@@ -120,14 +200,16 @@ func (r *userRepository) Delete(id int) error {
 }
 
 // ExistsByEmail checks if a user with the given email exists
-func (r *userRepository) ExistsByEmail(email string) (bool, error) {
+func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	ctx, cancel := r.db.queryContext(ctx)
+	defer cancel()
+
 	// Normalize email for case-insensitive lookup
 	email = normalizeEmail(email)
 	var count int64
 	// Use LOWER() for defensive case-insensitive matching (handles existing mixed-case data)
-	if err := r.db.Model(&models.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error; err != nil {
-		return false, fmt.Errorf("failed to check if email %s exists: %w", email, err)
+	if err := r.reader(ctx).Model(&models.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check if email exists: %w", err)
 	}
 	return count > 0, nil
 }
-
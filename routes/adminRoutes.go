@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/container"
+	"github.com/leventeberry/goapi/controllers"
+	"github.com/leventeberry/goapi/middleware"
+)
+
+// SetupAdminRoutes registers admin-only operational routes on the provided Gin router group
+// Every route requires authentication and the admin role, and is rate limited per user
+func SetupAdminRoutes(router *gin.RouterGroup, c *container.Container) {
+	adminGroup := router.Group("/admin")
+	adminGroup.Use(middleware.AuthMiddleware(), c.RateLimiters.Middleware(middleware.RateLimitPolicyUser), middleware.RequireRole("admin"))
+	{
+		// Cache inspection
+		adminGroup.GET("/cache/stats", controllers.GetCacheStats(c.CacheService))
+		adminGroup.GET("/cache/keys", controllers.InspectCacheKey(c.CacheService))
+		adminGroup.DELETE("/cache/keys", controllers.EvictCachePrefix(c.CacheService))
+
+		// IP allow/deny rules and automatic bans
+		adminGroup.GET("/ip-rules", controllers.GetIPRules(c.IPFilterService))
+		adminGroup.POST("/ip-rules", controllers.AddIPRule(c.IPFilterService))
+		adminGroup.DELETE("/ip-rules", controllers.DeleteIPRule(c.IPFilterService))
+		adminGroup.GET("/ip-bans/:ip", controllers.GetIPBan(c.IPFilterService))
+		adminGroup.DELETE("/ip-bans/:ip", controllers.DeleteIPBan(c.IPFilterService))
+
+		// Audit log query and JSONL export
+		adminGroup.GET("/audit", controllers.GetAuditEvents(c.AuditService))
+	}
+}
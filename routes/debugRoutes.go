@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/controllers"
+	"github.com/leventeberry/goapi/middleware"
+)
+
+// SetupDebugRoutes registers the admin/debug endpoints on the debug listener's Gin engine
+// These expose process internals and must never be mounted on the public router
+func SetupDebugRoutes(router *gin.Engine) {
+	cfg := config.Get()
+	debug := router.Group("/debug")
+	if cfg.Debug.Username != "" && cfg.Debug.Password != "" {
+		debug.Use(gin.BasicAuth(gin.Accounts{cfg.Debug.Username: cfg.Debug.Password}))
+	}
+	debug.Use(middleware.ErrorHandler(controllers.ProblemFor))
+
+	// net/http/pprof: CPU (/profile), heap, goroutine, block, mutex and execution trace profiles
+	debug.Any("/pprof/*profile", pprofHandler)
+
+	// expvar: memstats, cmdline and any published variables
+	debug.GET("/vars", gin.WrapH(expvar.Handler()))
+
+	debug.GET("/buildinfo", controllers.GetBuildInfo())
+	debug.GET("/config", controllers.GetConfig())
+	debug.GET("/log-level", controllers.GetLogLevel())
+	debug.PUT("/log-level", controllers.SetLogLevel())
+}
+
+// pprofHandler dispatches /debug/pprof/* to the net/http/pprof handlers
+// Named profiles (heap, goroutine, ...) and the index page are served by pprof.Index
+func pprofHandler(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("profile"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/health"
+)
+
+// SetupHealthRoutes registers the liveness, readiness and startup probes on the provided Gin engine
+func SetupHealthRoutes(router *gin.Engine, registry *health.Registry) {
+	// Liveness probe
+	// @Summary      Liveness probe
+	// @Description  Reports whether the process is alive. Never checks dependencies, so a database outage does not restart the pod
+	// @Tags         health
+	// @Produce      json
+	// @Param        verbose  query     bool          false  "Include per-check results"
+	// @Success      200      {object}  health.Report  "Alive"
+	// @Failure      503      {object}  health.Report  "Not alive"
+	// @Router       /livez [get]
+	router.GET("/livez", probeHandler(registry, health.Liveness))
+
+	// Readiness probe
+	// @Summary      Readiness probe
+	// @Description  Reports whether the instance should receive traffic: database and disk must be healthy; cache and replica failures are reported as warnings. Fails during graceful shutdown
+	// @Tags         health
+	// @Produce      json
+	// @Param        verbose  query     bool          false  "Include per-check results"
+	// @Success      200      {object}  health.Report  "Ready"
+	// @Failure      503      {object}  health.Report  "Not ready"
+	// @Router       /readyz [get]
+	router.GET("/readyz", probeHandler(registry, health.Readiness))
+
+	// Startup probe
+	// @Summary      Startup probe
+	// @Description  Reports whether initialization has completed: database reachable and migrations applied
+	// @Tags         health
+	// @Produce      json
+	// @Param        verbose  query     bool          false  "Include per-check results"
+	// @Success      200      {object}  health.Report  "Started"
+	// @Failure      503      {object}  health.Report  "Still starting"
+	// @Router       /startupz [get]
+	router.GET("/startupz", probeHandler(registry, health.Startup))
+
+	// Health check endpoint (kept for existing monitors in its original format)
+	// @Summary      Health check
+	// @Description  Database and cache status backed by the readiness checks. Prefer /livez, /readyz and /startupz
+	// @Tags         health
+	// @Produce      json
+	// @Success      200  {object}  map[string]interface{}  "All systems healthy"
+	// @Failure      503  {object}  map[string]interface{}  "Service unavailable"
+	// @Router       /health [get]
+	router.GET("/health", legacyHealthHandler(registry))
+}
+
+// probeHandler runs the checks for probe
+// Only failing checks are listed unless ?verbose is set
+func probeHandler(registry *health.Registry, probe health.Probe) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, verbose := c.GetQuery("verbose")
+		writeReport(c, registry.Run(c.Request.Context(), probe), verbose)
+	}
+}
+
+// writeReport responds with 200 when the probe passed and 503 otherwise
+func writeReport(c *gin.Context, report health.Report, verbose bool) {
+	if !verbose {
+		var failed []health.CheckResult
+		for _, check := range report.Checks {
+			if check.Status != health.StatusOK {
+				failed = append(failed, check)
+			}
+		}
+		report.Checks = failed
+	}
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// Statuses of the /health response, which predates the probe endpoints
+const (
+	legacyHealthy   = "healthy"
+	legacyUnhealthy = "unhealthy"
+	legacyDisabled  = "disabled"
+)
+
+// legacyHealthHandler serves /health with its original body:
+// {"status": "healthy", "timestamp": 1700000000, "database": {"status": "healthy"}, "cache": {"status": "healthy"}}
+// The status follows the readiness probe, so /health also fails while the server drains on shutdown
+// A cache failure is reported without failing the check, as before
+func legacyHealthHandler(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := registry.Run(c.Request.Context(), health.Readiness)
+
+		body := gin.H{
+			"status":    legacyHealthy,
+			"timestamp": time.Now().Unix(),
+			"cache":     gin.H{"status": legacyDisabled},
+		}
+		for _, check := range report.Checks {
+			if check.Name == "database" || check.Name == "cache" {
+				body[check.Name] = legacyCheck(check)
+			}
+		}
+
+		status := http.StatusOK
+		if !report.OK() {
+			body["status"] = legacyUnhealthy
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, body)
+	}
+}
+
+// legacyCheck converts a check result to the /health format
+func legacyCheck(check health.CheckResult) gin.H {
+	if check.Status == health.StatusOK {
+		return gin.H{"status": legacyHealthy}
+	}
+	return gin.H{"status": legacyUnhealthy, "error": check.Error}
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/health"
+)
+
+// healthRouter serves the health routes backed by a database check and an optional cache check
+func healthRouter(dbErr, cacheErr error, withCache, shuttingDown bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	registry := health.NewRegistry(0, time.Second)
+	registry.Register(health.NewChecker("database", func(context.Context) error { return dbErr }), health.Readiness, health.Startup)
+	if withCache {
+		registry.RegisterOptional(health.NewChecker("cache", func(context.Context) error { return cacheErr }), health.Readiness)
+	}
+	registry.MarkStarted()
+	if shuttingDown {
+		registry.MarkShuttingDown()
+	}
+
+	router := gin.New()
+	SetupHealthRoutes(router, registry)
+	return router
+}
+
+func TestLegacyHealth(t *testing.T) {
+	tests := []struct {
+		name         string
+		dbErr        error
+		cacheErr     error
+		withCache    bool
+		shuttingDown bool
+		wantCode     int
+		wantStatus   string
+		wantDatabase map[string]any
+		wantCache    map[string]any
+	}{
+		{
+			name:         "healthy",
+			withCache:    true,
+			wantCode:     http.StatusOK,
+			wantStatus:   "healthy",
+			wantDatabase: map[string]any{"status": "healthy"},
+			wantCache:    map[string]any{"status": "healthy"},
+		},
+		{
+			name:         "cache disabled",
+			wantCode:     http.StatusOK,
+			wantStatus:   "healthy",
+			wantDatabase: map[string]any{"status": "healthy"},
+			wantCache:    map[string]any{"status": "disabled"},
+		},
+		{
+			name:         "cache down does not fail the check",
+			cacheErr:     errors.New("connection refused"),
+			withCache:    true,
+			wantCode:     http.StatusOK,
+			wantStatus:   "healthy",
+			wantDatabase: map[string]any{"status": "healthy"},
+			wantCache:    map[string]any{"status": "unhealthy", "error": "connection refused"},
+		},
+		{
+			name:         "database down",
+			dbErr:        errors.New("connection refused"),
+			withCache:    true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatus:   "unhealthy",
+			wantDatabase: map[string]any{"status": "unhealthy", "error": "connection refused"},
+			wantCache:    map[string]any{"status": "healthy"},
+		},
+		{
+			name:         "draining on shutdown",
+			withCache:    true,
+			shuttingDown: true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatus:   "unhealthy",
+			wantDatabase: map[string]any{"status": "healthy"},
+			wantCache:    map[string]any{"status": "healthy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := healthRouter(tt.dbErr, tt.cacheErr, tt.withCache, tt.shuttingDown)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d", w.Code, tt.wantCode)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if body["status"] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", body["status"], tt.wantStatus)
+			}
+			if timestamp, ok := body["timestamp"].(float64); !ok || int64(timestamp) < time.Now().Add(-time.Minute).Unix() {
+				t.Errorf("timestamp = %v, want current unix seconds", body["timestamp"])
+			}
+			assertObject(t, "database", body["database"], tt.wantDatabase)
+			assertObject(t, "cache", body["cache"], tt.wantCache)
+		})
+	}
+}
+
+// assertObject compares a decoded JSON object with want
+func assertObject(t *testing.T, name string, got any, want map[string]any) {
+	t.Helper()
+	object, ok := got.(map[string]any)
+	if !ok || len(object) != len(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+		return
+	}
+	for key, value := range want {
+		if object[key] != value {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+func TestProbesWhileDraining(t *testing.T) {
+	tests := []struct {
+		path         string
+		shuttingDown bool
+		want         int
+	}{
+		{"/livez", false, http.StatusOK},
+		{"/readyz", false, http.StatusOK},
+		{"/startupz", false, http.StatusOK},
+		{"/livez", true, http.StatusOK},
+		{"/readyz", true, http.StatusServiceUnavailable},
+		{"/startupz", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		router := healthRouter(nil, nil, true, tt.shuttingDown)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s (shutting down: %v) = %d, want %d", tt.path, tt.shuttingDown, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/metrics"
+)
+
+// SetupMetricsRoutes registers the Prometheus scrape endpoint on the provided Gin engine
+// The endpoint is protected with basic auth when METRICS_USERNAME and METRICS_PASSWORD are set
+// config.Load rejects setting only one of them
+func SetupMetricsRoutes(router *gin.Engine) {
+	cfg := config.Get()
+	handlers := []gin.HandlerFunc{}
+	if cfg.Metrics.Username != "" {
+		handlers = append(handlers, gin.BasicAuth(gin.Accounts{cfg.Metrics.Username: cfg.Metrics.Password}))
+	}
+
+	// Prometheus metrics
+	// @Summary      Prometheus metrics
+	// @Description  Exposes HTTP, rate limit, login, database pool and cache metrics in Prometheus text format
+	// @Tags         health
+	// @Produce      plain
+	// @Success      200  {string}  string  "Metrics in Prometheus exposition format"
+	// @Failure      401  {string}  string  "Missing or invalid basic auth credentials"
+	// @Router       /metrics [get]
+	router.GET("/metrics", append(handlers, gin.WrapH(metrics.Handler()))...)
+}
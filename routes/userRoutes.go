@@ -8,14 +8,14 @@ import (
 )
 
 // SetupUserRoutes registers all user-related routes on the provided Gin router group
-// All user routes are protected by authentication middleware
+// All user routes are protected by authentication middleware and rate limited per user
 // Admin-only routes use RequireRole middleware for role-based access control
 // Uses dependency injection container for all dependencies
 // Accepts a *gin.RouterGroup to support versioned routes (e.g., /api/v1)
 func SetupUserRoutes(router *gin.RouterGroup, c *container.Container) {
-	// User routes group with authentication middleware
+	// User routes group with authentication and per-user rate limiting
 	userGroup := router.Group("/users")
-	userGroup.Use(middleware.AuthMiddleware())
+	userGroup.Use(middleware.AuthMiddleware(), c.RateLimiters.Middleware(middleware.RateLimitPolicyUser))
 	{
 		// Public authenticated routes (any authenticated user can access)
 		userGroup.GET("", controllers.GetUsers(c.UserService))
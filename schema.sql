@@ -1,5 +1,5 @@
--- PostgreSQL schema for users table
--- Note: GORM AutoMigrate will create this table automatically based on the User model
+-- PostgreSQL schema for the users and audit_events tables
+-- Note: GORM AutoMigrate will create these tables automatically based on the User and AuditEvent models
 -- This schema is provided as a reference for manual database setup
 
 DROP TABLE IF EXISTS users;
@@ -32,6 +32,42 @@ CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
 -- Create index on email for faster lookups
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 
+-- Append-only audit log of security-relevant actions (see models.AuditEvent)
+DROP TABLE IF EXISTS audit_events;
+
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    actor_id BIGINT,
+    actor_role VARCHAR(20),
+    target_type VARCHAR(20),
+    target_id VARCHAR(255),
+    changes JSONB,
+    ip VARCHAR(45),
+    request_id VARCHAR(128)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events(request_id);
+
+-- Refuse updates, deletes and truncation of audit events at the database level (see models.AuditEventTriggerSQL)
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only' USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
 -- Note: Do not insert users with plaintext passwords
 -- Use the /register endpoint to create users with properly hashed passwords
 -- Example seed data (with bcrypt hashed passwords) can be added manually if needed:
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/repositories"
+	"github.com/rs/zerolog"
+)
+
+// auditService implements AuditService interface
+type auditService struct {
+	auditRepo repositories.AuditRepository
+}
+
+// NewAuditService creates a new instance of AuditService
+// Factory function for creating audit service
+func NewAuditService(auditRepo repositories.AuditRepository) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// ListEvents retrieves audit events matching filter, newest first
+func (s *auditService) ListEvents(ctx context.Context, filter *AuditFilter, params *PaginationParams) ([]models.AuditEvent, int64, error) {
+	repoFilter, err := filter.toRepository()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Validate and set defaults
+	page := params.Page
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize := params.PageSize
+	if pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+	if pageSize > 100 {
+		pageSize = 100 // Max page size to prevent abuse
+	}
+
+	events, total, err := s.auditRepo.FindWithPagination(ctx, repoFilter, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit events: %w", err)
+	}
+	return events, total, nil
+}
+
+// ExportEvents calls fn for every audit event matching filter, oldest first
+func (s *auditService) ExportEvents(ctx context.Context, filter *AuditFilter, fn func(event *models.AuditEvent) error) error {
+	repoFilter, err := filter.toRepository()
+	if err != nil {
+		return err
+	}
+	return s.auditRepo.Each(ctx, repoFilter, fn)
+}
+
+// toRepository validates the filter and converts it to the repository filter
+func (f *AuditFilter) toRepository() (repositories.AuditFilter, error) {
+	if f == nil {
+		return repositories.AuditFilter{}, nil
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return repositories.AuditFilter{}, ErrInvalidAuditFilter
+	}
+	return repositories.AuditFilter{
+		Action:     f.Action,
+		ActorID:    f.ActorID,
+		TargetType: f.TargetType,
+		TargetID:   f.TargetID,
+		IP:         f.IP,
+		RequestID:  f.RequestID,
+		From:       f.From,
+		To:         f.To,
+	}, nil
+}
+
+// fieldChange is the before/after value of one changed field
+// A nil side means the record did not exist (creation or deletion)
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// secretChange marks a secret field as changed without recording its values
+var secretChange = map[string]bool{"changed": true}
+
+// auditedUserFields lists the user fields compared by diffUsers, by JSON name
+var auditedUserFields = []string{"first_name", "last_name", "email", "phone_number", "role"}
+
+// userAuditFields returns the audited fields of a user; nil for a user that doesn't exist
+func userAuditFields(user *models.User) map[string]any {
+	if user == nil {
+		return nil
+	}
+	return map[string]any{
+		"first_name":   user.FirstName,
+		"last_name":    user.LastName,
+		"email":        user.Email,
+		"phone_number": user.PhoneNum,
+		"role":         user.Role,
+	}
+}
+
+// diffUsers returns the changes between two versions of a user
+// Pass nil as before for a created user and as after for a deleted one
+// The password hash is never recorded; a password change only shows up as changed
+func diffUsers(before, after *models.User) map[string]any {
+	beforeFields, afterFields := userAuditFields(before), userAuditFields(after)
+	changes := make(map[string]any)
+	for _, field := range auditedUserFields {
+		if beforeFields[field] != afterFields[field] {
+			changes[field] = fieldChange{Before: beforeFields[field], After: afterFields[field]}
+		}
+	}
+	if before != nil && after != nil && before.PassHash != after.PassHash {
+		changes["password"] = secretChange
+	}
+	return changes
+}
+
+// newAuditEvent builds an event for action on a target
+// The actor, client IP and request ID are taken from the request context
+func newAuditEvent(ctx context.Context, action, targetType, targetID string, changes map[string]any) *models.AuditEvent {
+	event := &models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         middleware.ClientIPFromContext(ctx),
+		RequestID:  middleware.RequestIDFromContext(ctx),
+	}
+	if actor, ok := middleware.ActorFromContext(ctx); ok {
+		event.ActorID = &actor.ID
+		event.ActorRole = actor.Role
+	}
+	if len(changes) > 0 {
+		// Marshaling plain strings and flags cannot fail
+		event.Changes, _ = json.Marshal(changes)
+	}
+	return event
+}
+
+// newUserAuditEvent builds an event for action on a user
+func newUserAuditEvent(ctx context.Context, action string, userID int, changes map[string]any) *models.AuditEvent {
+	return newAuditEvent(ctx, action, models.AuditTargetUser, strconv.Itoa(userID), changes)
+}
+
+// asSelf makes user the actor of event, for actions taken before the request is authenticated
+func asSelf(event *models.AuditEvent, user *models.User) *models.AuditEvent {
+	id := user.ID
+	event.ActorID, event.ActorRole = &id, user.Role
+	return event
+}
+
+// recordAudit appends an event that isn't tied to a database change (best effort)
+// A failure is logged rather than returned so the audit log being unavailable doesn't block logins
+// The write is detached from the request so a client hanging up doesn't lose the event
+func recordAudit(ctx context.Context, auditRepo repositories.AuditRepository, event *models.AuditEvent) {
+	if err := auditRepo.Create(context.WithoutCancel(ctx), event); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("action", event.Action).Str("target_id", event.TargetID).Msg("Failed to record audit event")
+	}
+}
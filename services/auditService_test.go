@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/leventeberry/goapi/models"
+)
+
+const testPassHash = "$2a$10$abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQ"
+
+// testUser returns a user with every audited field and a password hash set
+func testUser() *models.User {
+	return &models.User{
+		ID:        7,
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Email:     "ada@example.com",
+		PassHash:  testPassHash,
+		PhoneNum:  "555-0100",
+		Role:      "user",
+	}
+}
+
+func TestDiffUsers(t *testing.T) {
+	newHash := "$2a$10$ZYXWVUTSRQPONMLKJIHGFEDCBA9876543210zyxwvutsrqponmlkji"
+	tests := []struct {
+		name       string
+		before     *models.User
+		after      func(*models.User) *models.User
+		wantFields []string
+	}{
+		{
+			name:       "created user",
+			after:      func(*models.User) *models.User { return testUser() },
+			wantFields: auditedUserFields,
+		},
+		{
+			name:       "deleted user",
+			before:     testUser(),
+			after:      func(*models.User) *models.User { return nil },
+			wantFields: auditedUserFields,
+		},
+		{
+			name:   "unchanged user",
+			before: testUser(),
+			after:  func(u *models.User) *models.User { return u },
+		},
+		{
+			name:   "password change",
+			before: testUser(),
+			after: func(u *models.User) *models.User {
+				u.PassHash = newHash
+				return u
+			},
+			wantFields: []string{"password"},
+		},
+		{
+			name:   "role and password change",
+			before: testUser(),
+			after: func(u *models.User) *models.User {
+				u.Role = "admin"
+				u.PassHash = newHash
+				return u
+			},
+			wantFields: []string{"role", "password"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var after *models.User
+			if tt.before != nil {
+				copied := *tt.before
+				after = tt.after(&copied)
+			} else {
+				after = tt.after(nil)
+			}
+			changes := diffUsers(tt.before, after)
+
+			if len(changes) != len(tt.wantFields) {
+				t.Errorf("changes = %v, want fields %v", changes, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := changes[field]; !ok {
+					t.Errorf("changes = %v, missing %q", changes, field)
+				}
+			}
+			if change, ok := changes["password"]; ok && !reflect.DeepEqual(change, secretChange) {
+				t.Errorf("password change = %v, want %v", change, secretChange)
+			}
+
+			event := newUserAuditEvent(context.Background(), models.AuditActionUserUpdate, 7, changes)
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			for _, secret := range []string{testPassHash, newHash, "pass_hash", "PassHash"} {
+				if strings.Contains(string(encoded), secret) {
+					t.Errorf("audit event leaks %q: %s", secret, encoded)
+				}
+			}
+		})
+	}
+}
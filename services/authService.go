@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/metrics"
 	"github.com/leventeberry/goapi/middleware"
 	"github.com/leventeberry/goapi/models"
 	"github.com/leventeberry/goapi/repositories"
@@ -10,22 +13,32 @@ import (
 
 // authService implements AuthService interface
 type authService struct {
-	userRepo repositories.UserRepository
+	userRepo  repositories.UserRepository
+	auditRepo repositories.AuditRepository
+	txManager repositories.TransactionManager
+	cache     cache.Cache
 }
 
 // NewAuthService creates a new instance of AuthService
 // Factory function for creating auth service
-func NewAuthService(userRepo repositories.UserRepository) AuthService {
+// Registrations and login attempts are recorded in the audit log
+func NewAuthService(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, txManager repositories.TransactionManager, cacheClient cache.Cache) AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		txManager: txManager,
+		cache:     cacheClient,
 	}
 }
 
 // Login authenticates a user and returns a JWT token
-func (s *authService) Login(email, password string) (*models.User, *middleware.Authentication, error) {
+func (s *authService) Login(ctx context.Context, email, password string) (*models.User, *middleware.Authentication, error) {
 	// Validate credentials
-	user, err := s.ValidateCredentials(email, password)
+	user, err := s.ValidateCredentials(ctx, email, password)
 	if err != nil {
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure).Inc()
+		// The attempted email is the target; it may not belong to any user
+		recordAudit(ctx, s.auditRepo, newAuditEvent(ctx, models.AuditActionLoginFailed, models.AuditTargetEmail, normalizeEmail(email), nil))
 		return nil, nil, err
 	}
 
@@ -34,12 +47,16 @@ func (s *authService) Login(email, password string) (*models.User, *middleware.A
 	if err != nil {
 		return nil, nil, ErrTokenGeneration
 	}
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSuccess).Inc()
+
+	// The user logging in is the actor; the request carries no token yet
+	recordAudit(ctx, s.auditRepo, asSelf(newUserAuditEvent(ctx, models.AuditActionLogin, user.ID, nil), user))
 
 	return user, token, nil
 }
 
 // Register creates a new user account and returns a JWT token
-func (s *authService) Register(input *RegisterInput) (*models.User, *middleware.Authentication, error) {
+func (s *authService) Register(ctx context.Context, input *RegisterInput) (*models.User, *middleware.Authentication, error) {
 	// Create user directly here to avoid circular dependency
 	// In a more advanced setup, we'd use a service orchestrator or composition
 
@@ -55,7 +72,7 @@ func (s *authService) Register(input *RegisterInput) (*models.User, *middleware.
 	}
 
 	// Check if email exists
-	exists, err := s.userRepo.ExistsByEmail(input.Email)
+	exists, err := s.userRepo.ExistsByEmail(ctx, input.Email)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to check email existence during registration: %w", err)
 	}
@@ -79,10 +96,25 @@ func (s *authService) Register(input *RegisterInput) (*models.User, *middleware.
 		Role:      role,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, nil, fmt.Errorf("failed to create user during registration: %w", err)
+	// Save to database together with the audit event
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, uow repositories.UnitOfWork) error {
+		if err := uow.Users().Create(ctx, user); err != nil {
+			// Covers losing a race with a concurrent registration of the same email
+			if cerr := constraintViolation(err); cerr != nil {
+				return cerr
+			}
+			return fmt.Errorf("failed to create user during registration: %w", err)
+		}
+		// A self-registration is its own actor
+		return uow.Audit().Create(ctx, asSelf(newUserAuditEvent(ctx, models.AuditActionRegister, user.ID, diffUsers(nil, user)), user))
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
+	// A lookup made before registration may have cached this user as missing
+	forgetNotFound(ctx, s.cache, user.ID, user.Email)
+
 	// Generate JWT token with user role
 	token, err := middleware.CreateToken(user.ID, user.Role)
 	if err != nil {
@@ -93,9 +125,9 @@ func (s *authService) Register(input *RegisterInput) (*models.User, *middleware.
 }
 
 // ValidateCredentials validates user email and password
-func (s *authService) ValidateCredentials(email, password string) (*models.User, error) {
+func (s *authService) ValidateCredentials(ctx context.Context, email, password string) (*models.User, error) {
 	// Find user by email
-	user, err := s.userRepo.FindByEmail(email)
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, ErrInvalidCredentials
 	}
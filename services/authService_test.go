@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/repositories"
+	"gorm.io/gorm/schema"
+)
+
+// unknownUserRepository finds no users
+type unknownUserRepository struct {
+	repositories.UserRepository
+}
+
+func (unknownUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return nil, repositories.ErrUserNotFound
+}
+
+// columnCheckingAuditRepository stores events, refusing values longer than their column like Postgres does
+type columnCheckingAuditRepository struct {
+	repositories.AuditRepository
+	events []*models.AuditEvent
+}
+
+func (r *columnCheckingAuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	auditSchema, err := schema.Parse(&models.AuditEvent{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+	if size := auditSchema.LookUpField("TargetID").Size; len(event.TargetID) > size {
+		return fmt.Errorf("value too long for type character varying(%d)", size)
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestLoginFailureIsAudited(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"short email", "Nobody@Example.com"},
+		{"longest accepted email", strings.Repeat("a", 64) + "@" + strings.Repeat("b", 178) + ".example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditRepo := &columnCheckingAuditRepository{}
+			service := NewAuthService(unknownUserRepository{}, auditRepo, nil, cache.NewNoOpCache())
+
+			if _, _, err := service.Login(context.Background(), tt.email, "password"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("Login error = %v, want ErrInvalidCredentials", err)
+			}
+			if len(auditRepo.events) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(auditRepo.events))
+			}
+			event := auditRepo.events[0]
+			if event.Action != models.AuditActionLoginFailed || event.TargetType != models.AuditTargetEmail || event.TargetID != strings.ToLower(tt.email) {
+				t.Errorf("event = %s %s %q, want a failed login for %q", event.Action, event.TargetType, event.TargetID, strings.ToLower(tt.email))
+			}
+		})
+	}
+}
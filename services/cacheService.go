@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/leventeberry/goapi/cache"
+	"github.com/rs/zerolog"
+)
+
+// cacheService implements CacheService interface
+type cacheService struct {
+	cache cache.Cache
+}
+
+// NewCacheService creates a new instance of CacheService
+// Factory function for creating the cache administration service
+func NewCacheService(cacheClient cache.Cache) CacheService {
+	return &cacheService{
+		cache: cacheClient,
+	}
+}
+
+// GetStats returns the cache counters per key family
+// Returns an empty list if the cache is not instrumented
+func (s *cacheService) GetStats() []cache.FamilyStats {
+	stats := cache.StatsOf(s.cache)
+	if stats == nil {
+		return []cache.FamilyStats{}
+	}
+	return stats.Snapshot()
+}
+
+// InspectKey returns the remaining lifetime of a cache key
+func (s *cacheService) InspectKey(ctx context.Context, key string) (*CacheKeyInfo, error) {
+	if key == "" {
+		return nil, ErrInvalidCacheKey
+	}
+
+	ttl, err := s.cache.TTL(ctx, key)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, ErrCacheKeyNotFound
+		}
+		return nil, err
+	}
+
+	info := &CacheKeyInfo{
+		Key:    key,
+		Family: cache.KeyFamily(key),
+	}
+	if ttl != cache.NoExpiration {
+		info.Expires = true
+		info.TTL = ttl
+	}
+	return info, nil
+}
+
+// EvictPrefix deletes every cache key starting with prefix
+// Prefixes that could reach protected keys (rate limits, IP rules and bans) are rejected
+func (s *cacheService) EvictPrefix(ctx context.Context, prefix string) (int, error) {
+	if cache.IsProtectedPrefix(prefix) {
+		return 0, ErrProtectedCachePrefix
+	}
+
+	deleted, err := s.cache.DeletePrefix(ctx, prefix)
+	if err != nil {
+		return deleted, err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("prefix", prefix).Int("deleted", deleted).Msg("Evicted cache keys by prefix")
+	return deleted, nil
+}
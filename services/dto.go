@@ -1,5 +1,7 @@
 package services
 
+import "time"
+
 // CreateUserInput holds the data for creating a new user
 type CreateUserInput struct {
 	FirstName string
@@ -36,3 +38,22 @@ type PaginationParams struct {
 	PageSize int
 }
 
+// CacheKeyInfo describes a single cache key
+type CacheKeyInfo struct {
+	Key     string
+	Family  string
+	Expires bool
+	TTL     time.Duration // remaining lifetime, zero if the key never expires
+}
+
+// AuditFilter narrows an audit log query; zero values match everything
+type AuditFilter struct {
+	Action     string
+	ActorID    *int
+	TargetType string
+	TargetID   string
+	IP         string
+	RequestID  string
+	From       time.Time // Inclusive
+	To         time.Time // Exclusive
+}
@@ -1,15 +1,56 @@
 package services
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/leventeberry/goapi/repositories"
+)
 
 // Service errors
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrEmailExists        = errors.New("email already registered")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrInvalidRole        = errors.New("invalid role")
-	ErrPasswordHashing    = errors.New("failed to hash password")
-	ErrNoFieldsToUpdate   = errors.New("at least one field must be provided for update")
-	ErrTokenGeneration    = errors.New("failed to generate token")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrEmailExists          = errors.New("email already registered")
+	ErrInvalidCredentials   = errors.New("invalid email or password")
+	ErrInvalidRole          = errors.New("invalid role")
+	ErrPasswordHashing      = errors.New("failed to hash password")
+	ErrNoFieldsToUpdate     = errors.New("at least one field must be provided for update")
+	ErrTokenGeneration      = errors.New("failed to generate token")
+	ErrConflict             = errors.New("conflicts with an existing resource")
+	ErrConstraintViolation  = errors.New("data violates a database constraint")
+	ErrInvalidCacheKey      = errors.New("cache key is required")
+	ErrCacheKeyNotFound     = errors.New("cache key not found")
+	ErrProtectedCachePrefix = errors.New("prefix would match protected cache keys")
+	ErrInvalidIPRule        = errors.New("invalid IP rule")
+	ErrIPRuleExists         = errors.New("IP rule already exists")
+	ErrIPRuleNotFound       = errors.New("IP rule not found")
+	ErrInvalidIPAddress     = errors.New("invalid IP address")
+	ErrIPBanNotFound        = errors.New("IP is not banned")
+	ErrIPFilterUnavailable  = errors.New("IP rules and bans need a cache backend that stores values")
+	ErrInvalidAuditFilter   = errors.New("invalid audit filter")
 )
 
+// traceErrorKinds are the errors spans are labeled with instead of their messages (see tracing.ErrorKind)
+var traceErrorKinds = []error{
+	ErrUserNotFound, ErrEmailExists, ErrInvalidCredentials, ErrInvalidRole, ErrPasswordHashing,
+	ErrNoFieldsToUpdate, ErrTokenGeneration, ErrConflict, ErrConstraintViolation,
+	repositories.ErrUserNotFound, repositories.ErrUserExists,
+	repositories.ErrUniqueViolation, repositories.ErrForeignKeyViolation,
+	repositories.ErrCheckViolation, repositories.ErrNotNullViolation,
+}
+
+// constraintViolation maps repository constraint violations to service errors
+// Returns nil if err is not a constraint violation
+func constraintViolation(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrUserExists):
+		return ErrEmailExists
+	case errors.Is(err, repositories.ErrUniqueViolation):
+		return ErrConflict
+	case errors.Is(err, repositories.ErrForeignKeyViolation),
+		errors.Is(err, repositories.ErrCheckViolation),
+		errors.Is(err, repositories.ErrNotNullViolation):
+		return ErrConstraintViolation
+	default:
+		return nil
+	}
+}
@@ -3,8 +3,9 @@ package services
 import (
 	"context"
 
-	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/cache"
 	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/models"
 )
 
 // UserService defines the interface for user business logic
@@ -21,8 +22,30 @@ type UserService interface {
 
 // AuthService defines the interface for authentication business logic
 type AuthService interface {
-	Login(email, password string) (*models.User, *middleware.Authentication, error)
-	Register(input *RegisterInput) (*models.User, *middleware.Authentication, error)
-	ValidateCredentials(email, password string) (*models.User, error)
+	Login(ctx context.Context, email, password string) (*models.User, *middleware.Authentication, error)
+	Register(ctx context.Context, input *RegisterInput) (*models.User, *middleware.Authentication, error)
+	ValidateCredentials(ctx context.Context, email, password string) (*models.User, error)
+}
+
+// CacheService defines the interface for cache administration
+type CacheService interface {
+	GetStats() []cache.FamilyStats
+	InspectKey(ctx context.Context, key string) (*CacheKeyInfo, error)
+	EvictPrefix(ctx context.Context, prefix string) (int, error)
 }
 
+// IPFilterService defines the interface for IP allow/deny rules and bans
+type IPFilterService interface {
+	ListRules(ctx context.Context) ([]middleware.IPRule, error)
+	AddRule(ctx context.Context, cidr, action, note string) (*middleware.IPRule, error)
+	RemoveRule(ctx context.Context, cidr, action string) error
+	GetBan(ctx context.Context, ip string) (*middleware.IPBan, error)
+	LiftBan(ctx context.Context, ip string) error
+}
+
+// AuditService defines the interface for querying the audit log
+// Events are recorded by the other services as part of the actions they audit
+type AuditService interface {
+	ListEvents(ctx context.Context, filter *AuditFilter, params *PaginationParams) ([]models.AuditEvent, int64, error)
+	ExportEvents(ctx context.Context, filter *AuditFilter, fn func(event *models.AuditEvent) error) error
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/rs/zerolog"
+)
+
+// ipFilterService implements IPFilterService interface
+type ipFilterService struct {
+	filter *middleware.IPFilter
+}
+
+// NewIPFilterService creates a new instance of IPFilterService
+// Factory function for creating the IP rule administration service
+func NewIPFilterService(filter *middleware.IPFilter) IPFilterService {
+	return &ipFilterService{
+		filter: filter,
+	}
+}
+
+// available fails every operation when admin rules and bans cannot be stored
+// Otherwise added rules would be accepted but never applied
+func (s *ipFilterService) available() error {
+	if !s.filter.Persistent() {
+		return ErrIPFilterUnavailable
+	}
+	return nil
+}
+
+// ListRules returns the config and admin-managed IP rules
+func (s *ipFilterService) ListRules(ctx context.Context) ([]middleware.IPRule, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	return s.filter.Rules(ctx)
+}
+
+// AddRule validates and stores an admin-managed IP rule
+func (s *ipFilterService) AddRule(ctx context.Context, cidr, action, note string) (*middleware.IPRule, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	rule, err := middleware.NewIPRule(cidr, action, note)
+	if err != nil {
+		return nil, ErrInvalidIPRule
+	}
+
+	added, err := s.filter.AddRule(ctx, *rule)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return nil, ErrIPRuleExists
+	}
+
+	zerolog.Ctx(ctx).Info().Str("cidr", rule.CIDR).Str("action", rule.Action).Msg("Added IP rule")
+	return rule, nil
+}
+
+// RemoveRule deletes an admin-managed IP rule
+func (s *ipFilterService) RemoveRule(ctx context.Context, cidr, action string) error {
+	if err := s.available(); err != nil {
+		return err
+	}
+	rule, err := middleware.NewIPRule(cidr, action, "")
+	if err != nil {
+		return ErrInvalidIPRule
+	}
+
+	removed, err := s.filter.RemoveRule(ctx, rule.CIDR, rule.Action)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrIPRuleNotFound
+	}
+
+	zerolog.Ctx(ctx).Info().Str("cidr", rule.CIDR).Str("action", rule.Action).Msg("Removed IP rule")
+	return nil
+}
+
+// GetBan returns the active ban for an IP
+func (s *ipFilterService) GetBan(ctx context.Context, ip string) (*middleware.IPBan, error) {
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	if _, err := netip.ParseAddr(ip); err != nil {
+		return nil, ErrInvalidIPAddress
+	}
+
+	ban, err := s.filter.Ban(ctx, s.filter.BanKey(ip))
+	if err != nil {
+		return nil, err
+	}
+	if ban == nil {
+		return nil, ErrIPBanNotFound
+	}
+	return ban, nil
+}
+
+// LiftBan removes the active ban for an IP
+func (s *ipFilterService) LiftBan(ctx context.Context, ip string) error {
+	ban, err := s.GetBan(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if err := s.filter.LiftBan(ctx, ban.IP); err != nil {
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().Str("ip", ban.IP).Msg("Lifted IP ban")
+	return nil
+}
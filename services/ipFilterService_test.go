@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/config"
+	"github.com/leventeberry/goapi/middleware"
+)
+
+func TestIPFilterServiceNeedsPersistentCache(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Proxy.IPv6PrefixLength = middleware.DefaultIPv6PrefixLength
+	cfg.IPFilter.RulesRefresh = time.Minute
+	service := NewIPFilterService(middleware.NewIPFilter(cache.NewNoOpCache(), cfg))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"ListRules", func() error { _, err := service.ListRules(ctx); return err }},
+		{"AddRule", func() error { _, err := service.AddRule(ctx, "203.0.113.0/24", middleware.IPRuleDeny, ""); return err }},
+		{"RemoveRule", func() error { return service.RemoveRule(ctx, "203.0.113.0/24", middleware.IPRuleDeny) }},
+		{"GetBan", func() error { _, err := service.GetBan(ctx, "203.0.113.7"); return err }},
+		{"LiftBan", func() error { return service.LiftBan(ctx, "203.0.113.7") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrIPFilterUnavailable) {
+				t.Errorf("error = %v, want ErrIPFilterUnavailable", err)
+			}
+		})
+	}
+}
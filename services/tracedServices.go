@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+
+	"github.com/leventeberry/goapi/middleware"
+	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracedUserService wraps a UserService with one span per method call
+// Spans are children of the request span, and parents of the cache and database spans
+type tracedUserService struct {
+	inner UserService
+}
+
+// NewTracedUserService decorates inner with tracing spans
+// Failed spans carry the service error kind, never the error message
+func NewTracedUserService(inner UserService) UserService {
+	tracing.RegisterErrorKinds(traceErrorKinds...)
+	return &tracedUserService{inner: inner}
+}
+
+// CreateUser traces UserService.CreateUser, tagging the span with the new user ID
+func (s *tracedUserService) CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	user, err := s.inner.CreateUser(ctx, input)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetUserByID traces UserService.GetUserByID
+func (s *tracedUserService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID", attribute.Int("user.id", id))
+	user, err := s.inner.GetUserByID(ctx, id)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetUserByEmail traces UserService.GetUserByEmail without recording the email
+func (s *tracedUserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	// The email itself is personal data and stays out of the span
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByEmail")
+	user, err := s.inner.GetUserByEmail(ctx, email)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetAllUsers traces UserService.GetAllUsers, tagging the span with the user count
+func (s *tracedUserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	users, err := s.inner.GetAllUsers(ctx)
+	span.SetAttributes(attribute.Int("users.count", len(users)))
+	tracing.End(span, err)
+	return users, err
+}
+
+// GetAllUsersPaginated traces UserService.GetAllUsersPaginated, tagging the span with the page size and total
+func (s *tracedUserService) GetAllUsersPaginated(ctx context.Context, params *PaginationParams) ([]models.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsersPaginated")
+	users, total, err := s.inner.GetAllUsersPaginated(ctx, params)
+	span.SetAttributes(attribute.Int("users.count", len(users)), attribute.Int64("users.total", total))
+	tracing.End(span, err)
+	return users, total, err
+}
+
+// UpdateUser traces UserService.UpdateUser
+func (s *tracedUserService) UpdateUser(ctx context.Context, id int, input *UpdateUserInput) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser", attribute.Int("user.id", id))
+	user, err := s.inner.UpdateUser(ctx, id, input)
+	tracing.End(span, err)
+	return user, err
+}
+
+// DeleteUser traces UserService.DeleteUser
+func (s *tracedUserService) DeleteUser(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser", attribute.Int("user.id", id))
+	err := s.inner.DeleteUser(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+// ValidateRole is not traced: it does no I/O
+func (s *tracedUserService) ValidateRole(role string) bool {
+	return s.inner.ValidateRole(role)
+}
+
+// tracedAuthService wraps an AuthService with one span per method call
+type tracedAuthService struct {
+	inner AuthService
+}
+
+// NewTracedAuthService decorates inner with tracing spans
+// Failed spans carry the service error kind, never the error message
+func NewTracedAuthService(inner AuthService) AuthService {
+	tracing.RegisterErrorKinds(traceErrorKinds...)
+	return &tracedAuthService{inner: inner}
+}
+
+// Login traces AuthService.Login without recording the email, tagging the span with the user ID on success
+func (s *tracedAuthService) Login(ctx context.Context, email, password string) (*models.User, *middleware.Authentication, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	user, auth, err := s.inner.Login(ctx, email, password)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, auth, err
+}
+
+// Register traces AuthService.Register, tagging the span with the new user ID
+func (s *tracedAuthService) Register(ctx context.Context, input *RegisterInput) (*models.User, *middleware.Authentication, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	user, auth, err := s.inner.Register(ctx, input)
+	if user != nil {
+		span.SetAttributes(attribute.Int("user.id", user.ID))
+	}
+	tracing.End(span, err)
+	return user, auth, err
+}
+
+// ValidateCredentials traces AuthService.ValidateCredentials without recording the email
+func (s *tracedAuthService) ValidateCredentials(ctx context.Context, email, password string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ValidateCredentials")
+	user, err := s.inner.ValidateCredentials(ctx, email, password)
+	tracing.End(span, err)
+	return user, err
+}
@@ -7,27 +7,50 @@ import (
 	"strings"
 
 	"github.com/leventeberry/goapi/cache"
-	"github.com/leventeberry/goapi/logger"
 	"github.com/leventeberry/goapi/middleware"
 	"github.com/leventeberry/goapi/models"
 	"github.com/leventeberry/goapi/repositories"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
 )
 
 // userService implements UserService interface
 type userService struct {
-	userRepo repositories.UserRepository
-	cache    cache.Cache
+	userRepo     repositories.UserRepository
+	auditRepo    repositories.AuditRepository
+	txManager    repositories.TransactionManager
+	cache        cache.Cache
+	usersByID    *cache.TypedCache[int, models.User]
+	usersByEmail *cache.TypedCache[string, models.User]
+	lookups      singleflight.Group
 }
 
 // NewUserService creates a new instance of UserService
 // Factory function for creating user service
-func NewUserService(userRepo repositories.UserRepository, cacheClient cache.Cache) UserService {
+// Every mutation is recorded in the audit log in the same transaction as the change
+func NewUserService(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, txManager repositories.TransactionManager, cacheClient cache.Cache, userCodec cache.Codec[models.User]) UserService {
 	return &userService{
-		userRepo: userRepo,
-		cache:    cacheClient,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		txManager:    txManager,
+		cache:        cacheClient,
+		usersByID:    cache.NewTypedCache(cacheClient, cache.PrefixKey[int](cache.UserIDKeyPrefix(userCodec.Name())), userCodec),
+		usersByEmail: cache.NewTypedCache(cacheClient, emailKey(cache.UserEmailKeyPrefix(userCodec.Name())), userCodec),
 	}
 }
 
+// emailKey builds user cache keys from the normalized email, so every casing of an address shares one entry
+func emailKey(prefix string) cache.KeyBuilder[string] {
+	return func(email string) string {
+		return prefix + normalizeEmail(email)
+	}
+}
+
+// normalizeEmail matches the form the repository stores emails in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 // CreateUser creates a new user with business logic validation
 func (s *userService) CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error) {
 	// Validate role
@@ -42,7 +65,7 @@ func (s *userService) CreateUser(ctx context.Context, input *CreateUserInput) (*
 	}
 
 	// Check if email already exists
-	exists, err := s.userRepo.ExistsByEmail(input.Email)
+	exists, err := s.userRepo.ExistsByEmail(ctx, input.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check email existence: %w", err)
 	}
@@ -66,17 +89,28 @@ func (s *userService) CreateUser(ctx context.Context, input *CreateUserInput) (*
 		Role:      role,
 	}
 
-	// Save to database
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	// Save to database together with the audit event
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, uow repositories.UnitOfWork) error {
+		if err := uow.Users().Create(ctx, user); err != nil {
+			// Covers losing a race with a concurrent registration of the same email
+			if cerr := constraintViolation(err); cerr != nil {
+				return cerr
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return uow.Audit().Create(ctx, newUserAuditEvent(ctx, models.AuditActionUserCreate, user.ID, diffUsers(nil, user)))
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Store in cache after successful creation
-	if err := s.cache.SetUserByID(ctx, user.ID, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache user by ID")
+	forgetNotFound(ctx, s.cache, user.ID, user.Email)
+	if err := s.usersByID.Set(ctx, user.ID, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache user by ID")
 	}
-	if err := s.cache.SetUserByEmail(ctx, user.Email, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Str("email", user.Email).Msg("Failed to cache user by email")
+	if err := s.usersByEmail.Set(ctx, user.Email, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("email", user.Email).Msg("Failed to cache user by email")
 	}
 
 	return user, nil
@@ -84,11 +118,11 @@ func (s *userService) CreateUser(ctx context.Context, input *CreateUserInput) (*
 
 // GetUserByID retrieves a user by ID using cache-aside pattern
 // 1. Check cache first
-// 2. If cache miss, query database
-// 3. Store result in cache for future requests
+// 2. If cache miss, query database (concurrent misses for the same ID share one query)
+// 3. Store result in cache for future requests, including a short-lived "not found" entry
 func (s *userService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	// Try to get from cache first
-	user, err := s.cache.GetUserByID(ctx, id)
+	user, err := s.usersByID.Get(ctx, id)
 	if err == nil {
 		// Cache hit - return cached user
 		return user, nil
@@ -96,35 +130,46 @@ func (s *userService) GetUserByID(ctx context.Context, id int) (*models.User, er
 
 	// Cache miss or error - fallback to database
 	if !errors.Is(err, cache.ErrCacheMiss) {
-		logger.Log.Warn().Err(err).Int("user_id", id).Msg("Cache error when fetching user by ID")
+		zerolog.Ctx(ctx).Warn().Err(err).Int("user_id", id).Msg("Cache error when fetching user by ID")
 	}
 
-	user, err = s.userRepo.FindByID(id)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
+	return s.coalesce(ctx, fmt.Sprintf("id:%d", id), func(ctx context.Context) (*models.User, error) {
+		notFoundKey := fmt.Sprintf("%s%d", cache.UserNotFoundIDKeyPrefix, id)
+		if missing, _ := s.cache.Exists(ctx, notFoundKey); missing {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by ID %d: %w", id, err)
-	}
 
-	// Store in cache for future requests (best effort - don't fail on cache error)
-	if err := s.cache.SetUserByID(ctx, id, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Int("user_id", id).Msg("Failed to cache user by ID")
-	}
-	if err := s.cache.SetUserByEmail(ctx, user.Email, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Str("email", user.Email).Msg("Failed to cache user by email")
-	}
+		user, err := s.userRepo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				s.cacheNotFound(ctx, notFoundKey)
+				return nil, ErrUserNotFound
+			}
+			return nil, fmt.Errorf("failed to get user by ID %d: %w", id, err)
+		}
 
-	return user, nil
+		// Store in cache for future requests (best effort - don't fail on cache error)
+		if err := s.usersByID.Set(ctx, id, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Int("user_id", id).Msg("Failed to cache user by ID")
+		}
+		if err := s.usersByEmail.Set(ctx, user.Email, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("email", user.Email).Msg("Failed to cache user by email")
+		}
+
+		return user, nil
+	})
 }
 
 // GetUserByEmail retrieves a user by email using cache-aside pattern
 // 1. Check cache first
-// 2. If cache miss, query database
-// 3. Store result in cache for future requests
+// 2. If cache miss, query database (concurrent misses for the same email share one query)
+// 3. Store result in cache for future requests, including a short-lived "not found" entry
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	// Normalized like the stored email so updates and registration can clear every entry for it
+	email = normalizeEmail(email)
+
 	// Try to get from cache first
-	user, err := s.cache.GetUserByEmail(ctx, email)
+	user, err := s.usersByEmail.Get(ctx, email)
 	if err == nil {
 		// Cache hit - return cached user
 		return user, nil
@@ -132,31 +177,86 @@ func (s *userService) GetUserByEmail(ctx context.Context, email string) (*models
 
 	// Cache miss or error - fallback to database
 	if !errors.Is(err, cache.ErrCacheMiss) {
-		logger.Log.Warn().Err(err).Str("email", email).Msg("Cache error when fetching user by email")
+		zerolog.Ctx(ctx).Warn().Err(err).Str("email", email).Msg("Cache error when fetching user by email")
 	}
 
-	user, err = s.userRepo.FindByEmail(email)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
+	return s.coalesce(ctx, "email:"+email, func(ctx context.Context) (*models.User, error) {
+		notFoundKey := cache.UserNotFoundEmailKeyPrefix + email
+		if missing, _ := s.cache.Exists(ctx, notFoundKey); missing {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by email %s: %w", email, err)
-	}
 
-	// Store in cache for future requests (best effort - don't fail on cache error)
-	if err := s.cache.SetUserByEmail(ctx, email, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Str("email", email).Msg("Failed to cache user by email")
+		user, err := s.userRepo.FindByEmail(ctx, email)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				s.cacheNotFound(ctx, notFoundKey)
+				return nil, ErrUserNotFound
+			}
+			return nil, fmt.Errorf("failed to get user by email: %w", err)
+		}
+
+		// Store in cache for future requests (best effort - don't fail on cache error)
+		if err := s.usersByEmail.Set(ctx, email, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("email", email).Msg("Failed to cache user by email")
+		}
+		if err := s.usersByID.Set(ctx, user.ID, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache user by ID")
+		}
+
+		return user, nil
+	})
+}
+
+// coalesce runs load at most once at a time per key and shares the result with every concurrent caller
+// Prevents a stampede of identical database queries when a hot cache key expires
+func (s *userService) coalesce(ctx context.Context, key string, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	// A request that already wrote must not share a lookup that may be reading a lagging replica
+	if repositories.UsePrimary(ctx) {
+		key += ":primary"
+	}
+
+	ch := s.lookups.DoChan(key, func() (interface{}, error) {
+		// The lookup is shared, so one caller going away must not cancel it for the others
+		// The repository query timeout still bounds it
+		return load(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Give each caller its own copy of the shared result
+		user := *res.Val.(*models.User)
+		return &user, nil
 	}
-	if err := s.cache.SetUserByID(ctx, user.ID, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache user by ID")
+}
+
+// cacheNotFound records that a lookup found no user (best effort)
+func (s *userService) cacheNotFound(ctx context.Context, key string) {
+	if err := s.cache.Set(ctx, key, "1", cache.UserNotFoundCacheTTL); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to cache user not found")
 	}
+}
 
-	return user, nil
+// forgetNotFound removes negative cache entries for a user that now exists (best effort)
+// Shared by every service that creates users or changes their email
+func forgetNotFound(ctx context.Context, cacheClient cache.Cache, id int, email string) {
+	for _, key := range []string{
+		fmt.Sprintf("%s%d", cache.UserNotFoundIDKeyPrefix, id),
+		cache.UserNotFoundEmailKeyPrefix + normalizeEmail(email),
+	} {
+		if err := cacheClient.Delete(ctx, key); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("Failed to delete user not found cache entry")
+		}
+	}
 }
 
 // GetAllUsers retrieves all users
 func (s *userService) GetAllUsers(ctx context.Context) ([]models.User, error) {
-	return s.userRepo.FindAll()
+	return s.userRepo.FindAll(ctx)
 }
 
 // GetAllUsersPaginated retrieves users with pagination support
@@ -175,7 +275,7 @@ func (s *userService) GetAllUsersPaginated(ctx context.Context, params *Paginati
 		pageSize = 100 // Max page size to prevent abuse
 	}
 
-	users, total, err := s.userRepo.FindAllWithPagination(page, pageSize)
+	users, total, err := s.userRepo.FindAllWithPagination(ctx, page, pageSize)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get paginated users: %w", err)
 	}
@@ -183,93 +283,119 @@ func (s *userService) GetAllUsersPaginated(ctx context.Context, params *Paginati
 }
 
 // UpdateUser updates a user with business logic validation
+// The read, email uniqueness check and update run in one transaction with the row locked,
+// so concurrent updates of the same user cannot interleave
 func (s *userService) UpdateUser(ctx context.Context, id int, input *UpdateUserInput) (*models.User, error) {
-	// Get existing user
-	user, err := s.userRepo.FindByID(id)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return nil, ErrUserNotFound
+	// Hash the new password before taking the row lock; bcrypt is deliberately slow
+	var passHash string
+	if input.Password != nil {
+		hash, err := middleware.HashPassword(*input.Password)
+		if err != nil {
+			return nil, ErrPasswordHashing
 		}
-		return nil, fmt.Errorf("failed to get user by ID %d for update: %w", id, err)
+		passHash = hash
 	}
 
-	// Store old email for cache invalidation if email is being changed
-	oldEmail := user.Email
+	var (
+		user     *models.User
+		oldEmail string
+	)
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, uow repositories.UnitOfWork) error {
+		repo := uow.Users()
 
-	// Validate at least one field is being updated
-	if input.FirstName == nil && input.LastName == nil && input.Email == nil &&
-		input.Password == nil && input.PhoneNum == nil && input.Role == nil {
-		return nil, ErrNoFieldsToUpdate
-	}
+		// Get existing user and lock it until commit
+		var err error
+		user, err = repo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to get user by ID %d for update: %w", id, err)
+		}
 
-	// Update fields if provided
-	if input.FirstName != nil {
-		user.FirstName = *input.FirstName
-	}
-	if input.LastName != nil {
-		user.LastName = *input.LastName
-	}
-	if input.PhoneNum != nil {
-		user.PhoneNum = *input.PhoneNum
-	}
+		// Store old email for cache invalidation if email is being changed
+		oldEmail = user.Email
+		before := *user
 
-	// Handle email update with uniqueness check
-	// Compare normalized emails to handle case differences
-	if input.Email != nil {
-		normalizedInputEmail := strings.ToLower(strings.TrimSpace(*input.Email))
-		normalizedCurrentEmail := strings.ToLower(strings.TrimSpace(user.Email))
-		if normalizedInputEmail != normalizedCurrentEmail {
-			exists, err := s.userRepo.ExistsByEmail(*input.Email)
-			if err != nil {
-				return nil, fmt.Errorf("failed to check email existence for update: %w", err)
+		// Validate at least one field is being updated
+		if input.FirstName == nil && input.LastName == nil && input.Email == nil &&
+			input.Password == nil && input.PhoneNum == nil && input.Role == nil {
+			return ErrNoFieldsToUpdate
+		}
+
+		// Update fields if provided
+		if input.FirstName != nil {
+			user.FirstName = *input.FirstName
+		}
+		if input.LastName != nil {
+			user.LastName = *input.LastName
+		}
+		if input.PhoneNum != nil {
+			user.PhoneNum = *input.PhoneNum
+		}
+
+		// Handle email update with uniqueness check
+		// Compare normalized emails to handle case differences
+		if input.Email != nil {
+			if normalizeEmail(*input.Email) != normalizeEmail(user.Email) {
+				exists, err := repo.ExistsByEmail(ctx, *input.Email)
+				if err != nil {
+					return fmt.Errorf("failed to check email existence for update: %w", err)
+				}
+				if exists {
+					return ErrEmailExists
+				}
+				user.Email = *input.Email // Repository will normalize on save
 			}
-			if exists {
-				return nil, ErrEmailExists
+		}
+
+		// Handle role update with validation
+		if input.Role != nil {
+			if !IsValidRole(*input.Role) {
+				return ErrInvalidRole
 			}
-			user.Email = *input.Email // Repository will normalize on save
+			user.Role = *input.Role
 		}
-	}
 
-	// Handle role update with validation
-	if input.Role != nil {
-		if !IsValidRole(*input.Role) {
-			return nil, ErrInvalidRole
+		if input.Password != nil {
+			user.PassHash = passHash
 		}
-		user.Role = *input.Role
-	}
 
-	// Handle password update with hashing
-	if input.Password != nil {
-		hash, err := middleware.HashPassword(*input.Password)
-		if err != nil {
-			return nil, ErrPasswordHashing
+		// Save updates
+		// The unique index still guards against a concurrent update claiming the same email
+		if err := repo.Update(ctx, user); err != nil {
+			if cerr := constraintViolation(err); cerr != nil {
+				return cerr
+			}
+			return fmt.Errorf("failed to update user ID %d: %w", id, err)
 		}
-		user.PassHash = hash
-	}
 
-	// Save updates
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, fmt.Errorf("failed to update user ID %d: %w", id, err)
+		// Role changes get their own action so they are easy to find
+		action := models.AuditActionUserUpdate
+		if user.Role != before.Role {
+			action = models.AuditActionUserRoleChange
+		}
+		return uow.Audit().Create(ctx, newUserAuditEvent(ctx, action, id, diffUsers(&before, user)))
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Invalidate cache - delete old entries
-	// If email changed, delete both old and new email keys
-	if input.Email != nil && *input.Email != oldEmail {
-		// Delete old email key
-		s.cache.DeleteUserByEmail(ctx, oldEmail)
-		// Delete ID key (will be repopulated on next read)
-		s.cache.DeleteUserByID(ctx, id)
-	} else {
-		// Delete all cached entries for this user (both ID and email)
-		s.cache.DeleteUser(ctx, id, user.Email)
-	}
+	// The repository normalized user.Email on save, so it compares with the stored old email
+	// If the email changed, the old email key goes too; the new one is written below
+	s.usersByID.Delete(ctx, id)
+	s.usersByEmail.Delete(ctx, oldEmail)
 
 	// Store updated user in cache for future requests
-	if err := s.cache.SetUserByID(ctx, user.ID, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache updated user by ID")
+	if user.Email != oldEmail {
+		forgetNotFound(ctx, s.cache, user.ID, user.Email)
+	}
+	if err := s.usersByID.Set(ctx, user.ID, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Int("user_id", user.ID).Msg("Failed to cache updated user by ID")
 	}
-	if err := s.cache.SetUserByEmail(ctx, user.Email, user, cache.UserCacheTTL); err != nil {
-		logger.Log.Warn().Err(err).Str("email", user.Email).Msg("Failed to cache updated user by email")
+	if err := s.usersByEmail.Set(ctx, user.Email, user, cache.WithJitter(cache.UserCacheTTL)); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("email", user.Email).Msg("Failed to cache updated user by email")
 	}
 
 	return user, nil
@@ -277,28 +403,41 @@ func (s *userService) UpdateUser(ctx context.Context, id int, input *UpdateUserI
 
 // DeleteUser deletes a user
 func (s *userService) DeleteUser(ctx context.Context, id int) error {
-	// Get user first to get email for cache invalidation
-	user, err := s.userRepo.FindByID(id)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return ErrUserNotFound
+	var email string
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, uow repositories.UnitOfWork) error {
+		repo := uow.Users()
+
+		// Get user first to get email for cache invalidation
+		user, err := repo.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return ErrUserNotFound
+			}
+			return err
 		}
-		return err
-	}
 
-	email := user.Email
+		email = user.Email
 
-	// Delete from database
-	err = s.userRepo.Delete(id)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return ErrUserNotFound
+		// Delete from database
+		err = repo.Delete(ctx, id)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return ErrUserNotFound
+			}
+			if cerr := constraintViolation(err); cerr != nil {
+				return cerr
+			}
+			return fmt.Errorf("failed to delete user ID %d: %w", id, err)
 		}
-		return fmt.Errorf("failed to delete user ID %d: %w", id, err)
+		return uow.Audit().Create(ctx, newUserAuditEvent(ctx, models.AuditActionUserDelete, id, diffUsers(user, nil)))
+	})
+	if err != nil {
+		return err
 	}
 
 	// Invalidate cache - delete all cached entries for this user
-	s.cache.DeleteUser(ctx, id, email)
+	s.usersByID.Delete(ctx, id)
+	s.usersByEmail.Delete(ctx, email)
 
 	return nil
 }
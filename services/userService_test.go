@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leventeberry/goapi/cache"
+	"github.com/leventeberry/goapi/models"
+	"github.com/leventeberry/goapi/repositories"
+)
+
+// memoryUserRepository stores users in memory, normalizing emails like the GORM repository
+type memoryUserRepository struct {
+	repositories.UserRepository
+	mu          sync.Mutex
+	users       map[int]models.User
+	emailLookup int
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emailLookup++
+	for _, user := range r.users {
+		if user.Email == strings.ToLower(strings.TrimSpace(email)) {
+			return &user, nil
+		}
+	}
+	return nil, repositories.ErrUserNotFound
+}
+
+func (r *memoryUserRepository) FindByIDForUpdate(ctx context.Context, id int) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		return &user, nil
+	}
+	return nil, repositories.ErrUserNotFound
+}
+
+func (r *memoryUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.FindByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	r.users[user.ID] = *user
+	return nil
+}
+
+// memoryUnitOfWork runs the transaction directly against the in-memory repositories
+type memoryUnitOfWork struct {
+	users repositories.UserRepository
+	audit repositories.AuditRepository
+}
+
+func (u memoryUnitOfWork) Users() repositories.UserRepository  { return u.users }
+func (u memoryUnitOfWork) Audit() repositories.AuditRepository { return u.audit }
+
+func (u memoryUnitOfWork) WithinTransaction(ctx context.Context, fn func(ctx context.Context, uow repositories.UnitOfWork) error) error {
+	return fn(ctx, u)
+}
+
+func TestGetUserByEmailNormalizesCacheKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		lookups    []string
+		newEmail   string   // applied through UpdateUser after the lookups when set
+		wantMissed []string // lookups that must not find the user afterwards
+		wantQuery  int      // repository email lookups, including ExistsByEmail and the final checks
+	}{
+		{
+			name:      "every casing shares one cache entry",
+			lookups:   []string{"Ada@Example.com", "ada@example.com", " ADA@EXAMPLE.COM "},
+			wantQuery: 1,
+		},
+		{
+			name:       "an email change evicts the entry cached under another casing",
+			lookups:    []string{"Ada@Example.com"},
+			newEmail:   "Countess@Example.com",
+			wantMissed: []string{"Ada@Example.com", "ada@example.com"},
+			wantQuery:  3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			user := *testUser()
+			user.Email = "ada@example.com"
+			repo := &memoryUserRepository{users: map[int]models.User{user.ID: user}}
+			uow := memoryUnitOfWork{users: repo, audit: &columnCheckingAuditRepository{}}
+			cacheClient := cache.NewMemoryCache(100)
+			service := NewUserService(repo, uow.audit, uow, cacheClient, cache.NewUserCodec(cache.JSONCodec[cache.CachedUser]{}))
+
+			for _, email := range tt.lookups {
+				if _, err := service.GetUserByEmail(ctx, email); err != nil {
+					t.Fatalf("GetUserByEmail(%q): %v", email, err)
+				}
+			}
+			if tt.newEmail != "" {
+				if _, err := service.UpdateUser(ctx, user.ID, &UpdateUserInput{Email: &tt.newEmail}); err != nil {
+					t.Fatalf("UpdateUser: %v", err)
+				}
+			}
+			for _, email := range tt.wantMissed {
+				if found, err := service.GetUserByEmail(ctx, email); !errors.Is(err, ErrUserNotFound) {
+					t.Errorf("GetUserByEmail(%q) after the email change = %+v, %v, want ErrUserNotFound", email, found, err)
+				}
+			}
+			if repo.emailLookup != tt.wantQuery {
+				t.Errorf("repository email lookups = %d, want %d", repo.emailLookup, tt.wantQuery)
+			}
+
+			deleted, _ := cacheClient.DeletePrefix(ctx, cache.UserEmailKeyPrefix(cache.CodecJSON))
+			if tt.newEmail == "" && deleted != 1 {
+				t.Errorf("cached %d email entries, want 1", deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormSpanKey stores the active span on the GORM statement between callbacks
+const gormSpanKey = "tracing:span"
+
+// gormSpan is the span started by before, with the operation it was started for
+type gormSpan struct {
+	span      trace.Span
+	operation string
+}
+
+// GormPlugin creates a client span for every GORM query, child of the span in the statement context
+// Only the parameterized SQL is recorded, never the bound values
+type GormPlugin struct {
+	// Role labels the connection ("primary", "replica-1", ...)
+	Role string
+}
+
+// NewGormPlugin creates a GORM tracing plugin for the connection with the given role
+func NewGormPlugin(role string) *GormPlugin {
+	return &GormPlugin{Role: role}
+}
+
+// Name implements gorm.Plugin
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin by registering before/after callbacks for every operation
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"select", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("tracing:before_"+h.operation, p.before(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after("tracing:after_"+h.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before starts the span and makes it current for the rest of the statement
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := Tracer().Start(db.Statement.Context, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNamePostgreSQL,
+				semconv.DBOperationName(operation),
+				attribute.String("db.role", p.Role),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, gormSpan{span: span, operation: operation})
+	}
+}
+
+// after annotates the span with the executed statement and its outcome
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	started, ok := value.(gormSpan)
+	if !ok {
+		return
+	}
+	span := started.span
+
+	if table := db.Statement.Table; table != "" {
+		span.SetName("db." + started.operation + " " + table)
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if sql := db.Statement.SQL.String(); sql != "" {
+		span.SetAttributes(semconv.DBQueryText(sql))
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", db.RowsAffected))
+
+	// A missing row is a normal lookup result, not a failed query
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
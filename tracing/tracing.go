@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/leventeberry/goapi/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName identifies spans created by this application
+const InstrumentationName = "github.com/leventeberry/goapi"
+
+// Exporters supported by TRACING_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ShutdownFunc flushes buffered spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Init installs the global tracer provider and W3C trace context propagation
+// With TRACING_EXPORTER=none spans are not recorded, but traceparent headers are still propagated
+func Init(ctx context.Context) (ShutdownFunc, error) {
+	cfg := config.Get().Tracing
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		exporter = exp
+	case ExporterOTLP:
+		// Endpoint, headers and TLS follow the standard OTEL_EXPORTER_OTLP_* variables
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = exp
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start begins a span named name as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the kind of err on span (if any) and ends it
+// The message is never exported: wrapped errors can embed request data such as emails
+func End(span trace.Span, err error) {
+	if err != nil {
+		kind := ErrorKind(err)
+		span.SetAttributes(semconv.ErrorTypeKey.String(kind))
+		span.SetStatus(codes.Error, kind)
+	}
+	span.End()
+}
+
+// errorKinds are the sentinel errors whose messages name a kind (see RegisterErrorKinds)
+var (
+	errorKindsMu sync.RWMutex
+	errorKinds   = []error{context.Canceled, context.DeadlineExceeded}
+)
+
+// RegisterErrorKinds lets spans name errors matching one of errs (by errors.Is) with that error's message
+// Only register sentinels with fixed messages
+func RegisterErrorKinds(errs ...error) {
+	errorKindsMu.Lock()
+	defer errorKindsMu.Unlock()
+	for _, err := range errs {
+		if !slices.Contains(errorKinds, err) {
+			errorKinds = append(errorKinds, err)
+		}
+	}
+}
+
+// ErrorKind names err without its message
+// Registered sentinels are named by their message, database errors by their SQLSTATE, and anything else by the Go type of the innermost error
+func ErrorKind(err error) string {
+	errorKindsMu.RLock()
+	for _, known := range errorKinds {
+		if errors.Is(err, known) {
+			errorKindsMu.RUnlock()
+			return known.Error()
+		}
+	}
+	errorKindsMu.RUnlock()
+
+	var sqlErr interface{ SQLState() string }
+	if errors.As(err, &sqlErr) {
+		return "SQLSTATE " + sqlErr.SQLState()
+	}
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(err) {
+		err = next
+	}
+	return fmt.Sprintf("%T", err)
+}
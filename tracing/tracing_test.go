@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errKnown = errors.New("user not found")
+
+func TestEndRecordsOnlyTheErrorKind(t *testing.T) {
+	RegisterErrorKinds(errKnown)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKind string
+	}{
+		{"registered sentinel", fmt.Errorf("failed to find user by email alice@example.com: %w", errKnown), "user not found"},
+		{"context deadline", fmt.Errorf("query for alice@example.com: %w", context.DeadlineExceeded), "context deadline exceeded"},
+		{"database error", fmt.Errorf("lookup alice@example.com: %w", &pgconn.PgError{Code: "22P02", Message: `invalid input syntax: "alice@example.com"`}), "SQLSTATE 22P02"},
+		{"unregistered error", fmt.Errorf("lookup alice@example.com: %w", errors.New("alice@example.com is odd")), "*errors.errorString"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			_, span := provider.Tracer("test").Start(context.Background(), "op")
+			End(span, tt.err)
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("ended %d spans, want 1", len(ended))
+			}
+			got := ended[0]
+			if got.Status().Code != codes.Error || got.Status().Description != tt.wantKind {
+				t.Errorf("status = %v %q, want Error %q", got.Status().Code, got.Status().Description, tt.wantKind)
+			}
+			exported := fmt.Sprint(got.Status(), got.Attributes(), got.Events())
+			if strings.Contains(exported, "alice") {
+				t.Errorf("span leaks the error message: %s", exported)
+			}
+		})
+	}
+}